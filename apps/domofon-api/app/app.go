@@ -3,6 +3,8 @@ package app
 import (
 	webServer "domofon-api/internal/transport/http"
 	httpHandlers "domofon-api/internal/transport/http/handler"
+	"domofon-api/pkg/apiKeys"
+	"domofon-api/pkg/auditLog"
 	"domofon-api/pkg/rosdomofon"
 
 	"go.uber.org/fx"
@@ -12,6 +14,8 @@ var App = fx.Options(
 	fx.Provide(
 		webServer.New,
 		rosdomofon.NewDomofon,
+		auditLog.New,
+		apiKeys.New,
 	),
 	httpHandlers.HttpHandlers,
 )
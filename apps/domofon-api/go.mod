@@ -2,10 +2,17 @@ module domofon-api
 
 replace domofon-api.gg/config => ../../pkg/config
 
+replace domofon-api.gg/redact => ../../pkg/redact
+
+replace domofon-api.gg/signing => ../../pkg/signing
+
 require (
 	domofon-api.gg/config v0.0.0-00010101000000-000000000000
+	domofon-api.gg/redact v0.0.0-00010101000000-000000000000
+	domofon-api.gg/signing v0.0.0-00010101000000-000000000000
 	github.com/gin-gonic/gin v1.10.1
 	github.com/imroc/req/v3 v3.53.0
+	go.etcd.io/bbolt v1.4.3
 	go.uber.org/fx v1.24.0
 )
 
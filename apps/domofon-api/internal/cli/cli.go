@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"domofon-api/pkg/apiKeys"
+	"domofon-api/pkg/rosdomofon"
+	"fmt"
+
+	"domofon-api.gg/config"
+)
+
+// Run executes a command line subcommand. It returns false if args do not name a known subcommand.
+func Run(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch args[0] {
+	case "hash-key":
+		return true, hashKey(args[1:])
+	case "doors":
+		return true, doors(args[1:])
+	default:
+		return false, nil
+	}
+}
+
+// hashKey prints an API key and its hash, to be put in KEY_HASH. A new key is generated if none is given.
+func hashKey(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: hash-key [KEY]")
+	}
+
+	key := ""
+	if len(args) == 1 {
+		key = args[0]
+	} else {
+		generated, err := apiKeys.Generate()
+		if err != nil {
+			return err
+		}
+		key = generated
+	}
+
+	fmt.Printf("Key:  %s\n", key)
+	fmt.Printf("Hash: %s\n", apiKeys.Hash(key))
+	return nil
+}
+
+// doors prints the addresses, entrances and keys of the account of REFRESH_TOKEN,
+// the key IDs are what KEY_ID and DOORS need.
+func doors(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: doors")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	account, err := rosdomofon.NewDomofon(cfg).Account()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Addresses:")
+	for _, address := range account.Addresses {
+		fmt.Printf("  %s\n", address)
+	}
+	fmt.Println("Entrances (adapter):")
+	for _, entrance := range account.Entrances {
+		fmt.Printf("  %s  %s\n", entrance.AdapterID, entrance.Address)
+		for _, key := range entrance.Keys {
+			fmt.Printf("    key %d\n", key.ID)
+		}
+	}
+	fmt.Println("Flats:")
+	for _, flat := range account.Flats {
+		fmt.Printf("  %d  adapter %s  %s\n", flat.ID, flat.AdapterID, flat.Address)
+	}
+	fmt.Println("Keys (KEY_ID):")
+	for _, key := range account.Keys {
+		fmt.Printf("  %d  adapter %s  %s\n", key.ID, key.AdapterID, key.Address)
+	}
+	return nil
+}
@@ -1,19 +1,30 @@
 package ApiRouters
 
 import (
+	"domofon-api/internal/transport/http/middleware"
+	"domofon-api/pkg/apiKeys"
+	"domofon-api/pkg/auditLog"
+
+	"domofon-api.gg/config"
+	"domofon-api.gg/signing"
 	"github.com/gin-gonic/gin"
 )
 
 type ApiRouters struct {
-	Public  *gin.RouterGroup
-	Private *gin.RouterGroup
+	Public   *gin.RouterGroup
+	Private  *gin.RouterGroup
+	Internal *gin.RouterGroup // Internal routes are called by sms-checker with signed requests
 }
 
-func CreateApiRoutes(gin *gin.Engine) *ApiRouters {
+func CreateApiRoutes(gin *gin.Engine, keyring *apiKeys.Keyring, audit *auditLog.Log, config *config.Config) *ApiRouters {
 	gin.MaxMultipartMemory = 1 << 20
 	publicRoute := gin.Group("/api")
+	privateRoute := gin.Group("/api", middleware.APIKey(keyring, audit))
+	internalRoute := gin.Group("/api/internal", middleware.Signature(signing.NewVerifier(config.SigningKey, signing.DefaultMaxSkew, audit), audit))
 
 	return &ApiRouters{
-		Public: publicRoute,
+		Public:   publicRoute,
+		Private:  privateRoute,
+		Internal: internalRoute,
 	}
 }
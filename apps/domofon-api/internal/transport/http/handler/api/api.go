@@ -2,6 +2,9 @@ package apiRoute
 
 import (
 	"domofon-api/internal/transport/http/handler/ApiRouters"
+	"domofon-api/internal/transport/http/middleware"
+	"domofon-api/pkg/apiKeys"
+	"domofon-api/pkg/auditLog"
 	"domofon-api/pkg/rosdomofon"
 
 	"domofon-api.gg/config"
@@ -13,6 +16,7 @@ type Route struct {
 	routers    *ApiRouters.ApiRouters
 	config     *config.Config
 	rosdomofon *rosdomofon.Domofon
+	audit      *auditLog.Log
 }
 
 type fxOpts struct {
@@ -20,6 +24,7 @@ type fxOpts struct {
 	ApiRouter  *ApiRouters.ApiRouters
 	Config     *config.Config
 	Rosdomofon *rosdomofon.Domofon
+	Audit      *auditLog.Log
 }
 
 func ApiRoute(opts fxOpts) *Route {
@@ -27,9 +32,16 @@ func ApiRoute(opts fxOpts) *Route {
 		routers:    opts.ApiRouter,
 		config:     opts.Config,
 		rosdomofon: opts.Rosdomofon,
+		audit:      opts.Audit,
 	}
 
-	opts.ApiRouter.Public.GET("/open", router.open)
+	opts.ApiRouter.Private.POST("/open", middleware.RequireScope(apiKeys.ScopeOpen, opts.Audit), router.openByKey)
+	opts.ApiRouter.Private.GET("/doors", middleware.RequireScope(apiKeys.ScopeDoors, opts.Audit), router.doorsList)
+	opts.ApiRouter.Private.POST("/doors/:name/open", middleware.RequireScope(apiKeys.ScopeOpen, opts.Audit), router.openDoorByName)
+	opts.ApiRouter.Private.GET("/audit", middleware.RequireScope(apiKeys.ScopeAudit, opts.Audit), router.auditList)
+
+	opts.ApiRouter.Internal.POST("/open", router.open)
+	opts.ApiRouter.Internal.POST("/audit", router.auditReport)
 
 	return router
 }
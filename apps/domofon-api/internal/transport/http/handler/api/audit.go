@@ -0,0 +1,135 @@
+package apiRoute
+
+import (
+	"domofon-api/internal/transport/http/httpError"
+	"domofon-api/pkg/auditLog"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type auditListDto struct {
+	From     string `form:"from"`     // RFC 3339 time or YYYY-MM-DD date
+	To       string `form:"to"`       // RFC 3339 time or YYYY-MM-DD date, excluded
+	Identity string `form:"identity"` // exact resident or client name
+	Outcome  string `form:"outcome"`  // opened, denied or failed
+	Channel  string `form:"channel"`  // sms or http
+	Door     string `form:"door"`     // door name
+	Limit    int    `form:"limit"`
+	Format   string `form:"format"` // json (default) or csv
+}
+
+type auditReportDto struct {
+	Door     string `json:"door"`
+	Identity string `json:"identity"`
+	Phone    string `json:"phone"`
+	Reason   string `json:"reason"`
+}
+
+// record writes an entry to the audit log; a failure is logged, the request goes on.
+func (h *Route) record(entry auditLog.Entry) {
+	if err := h.audit.Add(entry); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// parseAuditTime parses a filter bound, either an RFC 3339 time or a local date.
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, value, time.Local)
+}
+
+func (h *Route) auditList(c *gin.Context) {
+	var req auditListDto
+	if err := c.ShouldBindQuery(&req); err != nil {
+		httpError.New(http.StatusBadRequest, httpError.CodeInvalidRequest, "invalid request").SendError(c)
+		return
+	}
+
+	from, err := parseAuditTime(req.From)
+	if err != nil {
+		httpError.New(http.StatusBadRequest, httpError.CodeInvalidRequest, "invalid from").SendError(c)
+		return
+	}
+	to, err := parseAuditTime(req.To)
+	if err != nil {
+		httpError.New(http.StatusBadRequest, httpError.CodeInvalidRequest, "invalid to").SendError(c)
+		return
+	}
+
+	entries, err := h.audit.Query(auditLog.Filter{
+		From:     from,
+		To:       to,
+		Identity: req.Identity,
+		Outcome:  auditLog.Outcome(req.Outcome),
+		Channel:  auditLog.Channel(req.Channel),
+		Door:     req.Door,
+		Limit:    req.Limit,
+	})
+	if err != nil {
+		fmt.Println(err)
+		httpError.New(http.StatusInternalServerError, httpError.CodeInternal, "internal server error on audit query").SendError(c)
+		return
+	}
+
+	switch req.Format {
+	case "", "json":
+		c.JSON(http.StatusOK, entries)
+	case "csv":
+		writeAuditCSV(c, entries)
+	default:
+		httpError.New(http.StatusBadRequest, httpError.CodeInvalidRequest, "unknown format").SendError(c)
+	}
+}
+
+// writeAuditCSV sends the entries as a CSV attachment.
+func writeAuditCSV(c *gin.Context, entries []auditLog.Entry) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="audit.csv"`)
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"time", "channel", "door", "identity", "phone", "ip", "route", "outcome", "reason", "error"})
+	for _, entry := range entries {
+		writer.Write([]string{
+			entry.Time.Format(time.RFC3339),
+			string(entry.Channel),
+			entry.Door,
+			entry.Identity,
+			entry.Phone,
+			entry.IP,
+			entry.Route,
+			string(entry.Outcome),
+			entry.Reason,
+			entry.Error,
+		})
+	}
+	writer.Flush()
+}
+
+// auditReport records an SMS attempt that sms-checker denied itself, so it never called open.
+func (h *Route) auditReport(c *gin.Context) {
+	var req auditReportDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpError.New(http.StatusBadRequest, httpError.CodeInvalidRequest, "invalid request").SendError(c)
+		return
+	}
+
+	h.record(auditLog.Entry{
+		Channel:  auditLog.ChannelSMS,
+		Door:     req.Door,
+		Identity: req.Identity,
+		Phone:    req.Phone,
+		Outcome:  auditLog.OutcomeDenied,
+		Reason:   req.Reason,
+	})
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
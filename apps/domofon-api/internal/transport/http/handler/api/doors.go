@@ -0,0 +1,47 @@
+package apiRoute
+
+import (
+	"domofon-api/internal/transport/http/httpError"
+	"domofon-api/pkg/rosdomofon"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// doorDto is a configured door. The allowed residents are left out, they are none of the client's business.
+type doorDto struct {
+	Name    string   `json:"name"`
+	KeyId   int      `json:"key_id"`
+	Aliases []string `json:"aliases"`
+	Primary bool     `json:"primary"`
+}
+
+type resDoorsDto struct {
+	Doors   []doorDto           `json:"doors"`
+	Account *rosdomofon.Account `json:"account"`
+}
+
+// doorsList returns the configured doors and the addresses, entrances, flats and keys of the Rosdomofon account,
+// so the KEY_ID of each door can be found without sniffing the mobile app.
+func (h *Route) doorsList(c *gin.Context) {
+	account, err := h.rosdomofon.Account()
+	if err != nil {
+		log.Printf("failed to list the account: %v\n", err)
+		httpError.FromRosdomofon(err).SendError(c)
+		return
+	}
+
+	primary := h.config.PrimaryDoor().Name
+	doors := make([]doorDto, 0)
+	for _, door := range h.config.AllDoors() {
+		doors = append(doors, doorDto{
+			Name:    door.Name,
+			KeyId:   door.KeyId,
+			Aliases: door.Aliases,
+			Primary: door.Name == primary,
+		})
+	}
+
+	c.JSON(http.StatusOK, resDoorsDto{Doors: doors, Account: account})
+}
@@ -1,63 +1,145 @@
 package apiRoute
 
 import (
-	"fmt"
+	"domofon-api/internal/transport/http/httpError"
+	"domofon-api/internal/transport/http/middleware"
+	"domofon-api/pkg/auditLog"
+	"domofon-api/pkg/rosdomofon"
+	"errors"
+	"log"
 	"net/http"
 
+	"domofon-api.gg/config"
 	"github.com/gin-gonic/gin"
 )
 
+// openDto is the body sent by sms-checker, so the audit log tells who opened by SMS.
 type openDto struct {
-	Code string `json:"code" form:"code" uri:"code" validate:"required"`
+	Door     string `json:"door"` // empty for the primary door
+	Channel  string `json:"channel"`
+	Identity string `json:"identity"`
+	Phone    string `json:"phone"`
 }
+
+// auditEntry returns the audit entry of the request.
+func (d openDto) auditEntry(c *gin.Context) auditLog.Entry {
+	entry := auditLog.Entry{
+		Channel:  auditLog.ChannelHTTP,
+		Identity: d.Identity,
+		IP:       c.ClientIP(),
+	}
+	if d.Channel == string(auditLog.ChannelSMS) {
+		entry.Channel = auditLog.ChannelSMS
+		entry.Phone = d.Phone
+	}
+	return entry
+}
+
 type resSigninDto struct {
 	Success bool `json:"success"`
 }
 
+// open opens the door for a signed request of sms-checker.
 func (h *Route) open(c *gin.Context) {
 	var req openDto
-	if err := c.ShouldBindQuery(&req); err != nil {
-		fmt.Println(err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println(err)
+		httpError.New(http.StatusBadRequest, httpError.CodeInvalidRequest, "invalid request").SendError(c)
 		return
 	}
 
-	if req.Code != h.config.SecretKey {
-		fmt.Printf("code: %s, need: %s\n", req.Code, h.config.SecretKey)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "wrong code"})
+	entry := req.auditEntry(c)
+	door, ok := h.findDoor(c, req.Door, entry)
+	if !ok {
 		return
 	}
 
-	//c.JSON(http.StatusOK, resSigninDto{true})
-	//return
+	// The checker checks the residents of the door too, this is the last line of defence
+	if entry.Channel == auditLog.ChannelSMS && !door.Allows(req.Identity) {
+		entry.Door = door.Name
+		entry.Outcome = auditLog.OutcomeDenied
+		entry.Reason = "door not allowed"
+		h.record(entry)
+		httpError.New(http.StatusForbidden, httpError.CodeForbidden, "door not allowed").SendError(c)
+		return
+	}
 
-	key, err := h.rosdomofon.CreateTemporaryKey(h.config.KeyId)
-	if err != nil {
-		fmt.Println(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error on create key"})
+	h.openDoor(c, door, entry)
+}
+
+// openByKey opens the primary door for a client authenticated by its API key.
+func (h *Route) openByKey(c *gin.Context) {
+	h.openDoor(c, h.config.PrimaryDoor(), h.keyEntry(c))
+}
+
+// openDoorByName opens the door named in the path for a client authenticated by its API key.
+func (h *Route) openDoorByName(c *gin.Context) {
+	entry := h.keyEntry(c)
+	door, ok := h.findDoor(c, c.Param("name"), entry)
+	if !ok {
 		return
 	}
-	fmt.Printf("key: %s\n", key)
 
-	err = h.rosdomofon.ActivateKey(key)
+	h.openDoor(c, door, entry)
+}
+
+// keyEntry returns the audit entry of a request authenticated by an API key.
+func (h *Route) keyEntry(c *gin.Context) auditLog.Entry {
+	return auditLog.Entry{
+		Channel:  auditLog.ChannelHTTP,
+		Identity: middleware.Client(c).Name,
+		IP:       c.ClientIP(),
+	}
+}
+
+// findDoor returns the door with the name or alias, or the primary door for an empty name.
+// An unknown door is recorded as denied and answered with 404.
+func (h *Route) findDoor(c *gin.Context, name string, entry auditLog.Entry) (config.Door, bool) {
+	if name == "" {
+		return h.config.PrimaryDoor(), true
+	}
+
+	door, ok := h.config.FindDoor(name)
+	if !ok {
+		entry.Door = name
+		entry.Outcome = auditLog.OutcomeDenied
+		entry.Reason = "unknown door"
+		h.record(entry)
+		httpError.New(http.StatusNotFound, httpError.CodeUnknownDoor, "unknown door "+name).SendError(c)
+	}
+	return door, ok
+}
+
+// openDoor opens the door for an authorized request and answers it.
+// The attempt is recorded in the audit log with entry, completed with the door and the outcome.
+func (h *Route) openDoor(c *gin.Context, door config.Door, entry auditLog.Entry) {
+	entry.Door = door.Name
+
+	key, err := h.rosdomofon.CreateTemporaryKey(door.KeyId)
+	if err == nil {
+		log.Printf("temporary key created for door %s\n", door.Name)
+		err = h.rosdomofon.ActivateKey(key)
+	}
 	if err != nil {
+		log.Printf("failed to open door %s: %v\n", door.Name, err)
+		entry.Outcome = auditLog.OutcomeFailed
+		entry.Error = auditError(err)
+		h.record(entry)
+		httpError.FromRosdomofon(err).SendError(c)
 		return
 	}
 
+	entry.Outcome = auditLog.OutcomeOpened
+	h.record(entry)
 	c.JSON(http.StatusOK, resSigninDto{true})
+}
 
-	//var reqData reqSigninDto
-	//if err := h.validator.ShouldBindJSON(c, &reqData); err != nil {
-	//	httpError.New(http.StatusBadRequest, err.Error()).SendError(c)
-	//	return
-	//}
-
-	//_, token, err := h.usersService.Sign(reqData.Email, reqData.Password)
-	//if err != nil {
-	//	err.(*httpError.HTTPError).SendError(c)
-	//	return
-	//}
-	//c.JSON(http.StatusOK, resSigninDto{
-	//	Token: "123",
-	//})
+// auditError returns what the audit log keeps of a failed opening: the stage, kind and status of the Rosdomofon error.
+// Its text is left out, the audit API serves the entries.
+func auditError(err error) string {
+	var upstream *rosdomofon.Error
+	if errors.As(err, &upstream) {
+		return upstream.Summary()
+	}
+	return "internal error"
 }
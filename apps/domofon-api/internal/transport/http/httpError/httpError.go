@@ -0,0 +1,82 @@
+package httpError
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"domofon-api/pkg/rosdomofon"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes of the API, stable for clients.
+const (
+	CodeInvalidRequest      = "invalid_request"
+	CodeUnauthorized        = "unauthorized"
+	CodeForbidden           = "forbidden"
+	CodeUnknownDoor         = "unknown_door"
+	CodeTokenRefreshFailed  = "token_refresh_failed"
+	CodeKeyCreationFailed   = "key_creation_failed"
+	CodeKeyActivationFailed = "key_activation_failed"
+	CodeAccountListFailed   = "account_list_failed"
+	CodeInternal            = "internal_error"
+)
+
+// HTTPError is the error response of the API:
+//
+//	{"error": "message", "code": "key_activation_failed", "upstream_status": 409}
+type HTTPError struct {
+	Status         int    `json:"-"`                         // Status is the HTTP status of the response.
+	Message        string `json:"error"`                     // Message is a human-readable description.
+	Code           string `json:"code"`                      // Code is the machine-readable error code.
+	UpstreamStatus int    `json:"upstream_status,omitempty"` // UpstreamStatus is the status returned by Rosdomofon, if any.
+}
+
+func New(status int, code, message string) *HTTPError {
+	return &HTTPError{Status: status, Code: code, Message: message}
+}
+
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
+// SendError writes the error as the response and aborts the request.
+func (e *HTTPError) SendError(c *gin.Context) {
+	c.AbortWithStatusJSON(e.Status, e)
+}
+
+// stageCodes maps the failed Rosdomofon stage to the error code.
+var stageCodes = map[rosdomofon.Stage]string{
+	rosdomofon.StageTokenRefresh: CodeTokenRefreshFailed,
+	rosdomofon.StageCreateKey:    CodeKeyCreationFailed,
+	rosdomofon.StageActivateKey:  CodeKeyActivationFailed,
+	rosdomofon.StageListAccount:  CodeAccountListFailed,
+}
+
+// FromRosdomofon converts an error of Rosdomofon to the error response. The message is fixed for the stage and status,
+// the underlying error stays in the server logs. The status tells callers whether to retry:
+//   - 504 Gateway Timeout: Rosdomofon did not answer in time, retry.
+//   - 503 Service Unavailable: Rosdomofon was unreachable, overloaded or failing, retry later.
+//   - 502 Bad Gateway: Rosdomofon refused the request or its answer was unusable, retrying will not help.
+func FromRosdomofon(err error) *HTTPError {
+	var upstream *rosdomofon.Error
+	if !errors.As(err, &upstream) {
+		return New(http.StatusInternalServerError, CodeInternal, "internal server error")
+	}
+
+	status, failure := http.StatusBadGateway, "failed"
+	switch {
+	case upstream.Timeout():
+		status, failure = http.StatusGatewayTimeout, "timed out"
+	case upstream.Temporary():
+		status, failure = http.StatusServiceUnavailable, "unavailable"
+	}
+
+	return &HTTPError{
+		Status:         status,
+		Code:           stageCodes[upstream.Stage],
+		Message:        fmt.Sprintf("rosdomofon %s %s", upstream.Stage, failure),
+		UpstreamStatus: upstream.StatusCode,
+	}
+}
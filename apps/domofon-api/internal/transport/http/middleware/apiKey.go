@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"domofon-api/internal/transport/http/httpError"
+	"domofon-api/pkg/apiKeys"
+	"domofon-api/pkg/auditLog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientKey is the gin context key of the authenticated API key.
+const clientKey = "apiKey"
+
+// APIKey authenticates the request by the key sent in "Authorization: Bearer <key>".
+// Rejected requests are recorded in the audit log as denied.
+func APIKey(keyring *apiKeys.Keyring, audit *auditLog.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		key := keyring.Authenticate(strings.TrimSpace(token))
+		if !ok || key == nil {
+			fmt.Printf("unauthorized request to %s from %s\n", c.FullPath(), c.ClientIP())
+			reason := "invalid API key"
+			if !ok {
+				reason = "missing API key"
+			}
+			recordDenied(audit, c, "", reason)
+			httpError.New(http.StatusUnauthorized, httpError.CodeUnauthorized, "unauthorized").SendError(c)
+			return
+		}
+
+		c.Set(clientKey, key)
+		c.Next()
+	}
+}
+
+// RequireScope rejects requests whose API key is not granted the scope, recording them in the audit log as denied.
+func RequireScope(scope string, audit *auditLog.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := Client(c)
+		if key == nil || !key.HasScope(scope) {
+			identity := ""
+			if key != nil {
+				identity = key.Name
+			}
+			recordDenied(audit, c, identity, "missing scope "+scope)
+			httpError.New(http.StatusForbidden, httpError.CodeForbidden, "forbidden").SendError(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Client returns the API key the request was authenticated with, or nil.
+func Client(c *gin.Context) *apiKeys.Key {
+	value, ok := c.Get(clientKey)
+	if !ok {
+		return nil
+	}
+	key, _ := value.(*apiKeys.Key)
+	return key
+}
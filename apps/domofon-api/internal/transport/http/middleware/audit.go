@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"fmt"
+
+	"domofon-api/pkg/auditLog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordDenied records in the audit log a request rejected by a middleware, before it reached its handler.
+// The identity claimed by an unauthenticated request is not trusted, so only the client address and the route are kept.
+func recordDenied(audit *auditLog.Log, c *gin.Context, identity, reason string) {
+	err := audit.Add(auditLog.Entry{
+		Channel:  auditLog.ChannelHTTP,
+		Identity: identity,
+		IP:       c.ClientIP(),
+		Route:    c.Request.Method + " " + c.Request.URL.Path,
+		Outcome:  auditLog.OutcomeDenied,
+		Reason:   reason,
+	})
+	if err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"domofon-api/internal/transport/http/httpError"
+	"domofon-api/pkg/auditLog"
+
+	"domofon-api.gg/signing"
+	"github.com/gin-gonic/gin"
+)
+
+// Signature accepts only requests signed with the shared key that were not seen before.
+// Rejected requests are recorded in the audit log as denied.
+func Signature(verifier *signing.Verifier, audit *auditLog.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := verifier.Verify(c.Request); err != nil {
+			fmt.Printf("rejected request to %s from %s: %v\n", c.FullPath(), c.ClientIP(), err)
+			recordDenied(audit, c, "", "signature: "+err.Error())
+			httpError.New(http.StatusUnauthorized, httpError.CodeUnauthorized, "unauthorized").SendError(c)
+			return
+		}
+		c.Next()
+	}
+}
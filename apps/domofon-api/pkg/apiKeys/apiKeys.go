@@ -0,0 +1,94 @@
+// Package apiKeys authenticates API clients by their key. Only the SHA-256 hashes of the keys are configured.
+package apiKeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"domofon-api.gg/config"
+)
+
+// Scopes a key may be granted.
+const (
+	ScopeOpen  = "open"  // ScopeOpen allows opening the door.
+	ScopeDoors = "doors" // ScopeDoors allows listing the doors and the Rosdomofon account, not opening them.
+	ScopeAudit = "audit" // ScopeAudit allows reading the audit log.
+	ScopeAll   = "*"     // ScopeAll allows everything.
+)
+
+// hashPrefix tells the hash algorithm of KEY_HASH.
+const hashPrefix = "sha256:"
+
+// Key is a configured API client.
+type Key struct {
+	Name   string   // Name identifies the client in the audit log.
+	Hash   string   // Hash is the hash of the key, see Hash.
+	Scopes []string // Scopes are what the client may do.
+}
+
+// HasScope reports whether the key is granted the scope.
+func (k *Key) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope) || slices.Contains(k.Scopes, ScopeAll)
+}
+
+// Keyring is the set of configured keys.
+type Keyring struct {
+	keys []Key
+}
+
+func New(config *config.Config) *Keyring {
+	keyring := &Keyring{}
+	for _, configured := range config.ApiKeys {
+		if !strings.HasPrefix(configured.KeyHash, hashPrefix) {
+			log.Printf("API key %s: ignoring KEY_HASH without the %q prefix", configured.Name, hashPrefix)
+			continue
+		}
+		keyring.keys = append(keyring.keys, Key{
+			Name:   configured.Name,
+			Hash:   configured.KeyHash,
+			Scopes: configured.Scopes,
+		})
+	}
+
+	if len(keyring.keys) == 0 {
+		log.Println("No API keys configured, private routes reject every request")
+	}
+	return keyring
+}
+
+// Generate returns a new random key.
+func Generate() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+// Hash returns the hash of a key, as expected in KEY_HASH. Keys are random, so a fast hash is enough.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+// Authenticate returns the configured key matching the key sent by a client, or nil.
+func (k *Keyring) Authenticate(key string) *Key {
+	if key == "" {
+		return nil
+	}
+
+	hash := []byte(Hash(key))
+	for i := range k.keys {
+		if subtle.ConstantTimeCompare(hash, []byte(k.keys[i].Hash)) == 1 {
+			return &k.keys[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+// Package auditLog keeps a persistent log of every door opening attempt, whatever its channel and outcome.
+package auditLog
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"domofon-api.gg/config"
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/fx"
+)
+
+// entriesBucket holds the entries keyed by time, so a time range is a cursor seek.
+var entriesBucket = []byte("entries")
+
+// noncesBucket holds the nonces of the signed requests of sms-checker with their signing time.
+var noncesBucket = []byte("nonces")
+
+// Channel is how an attempt reached the service.
+type Channel string
+
+const (
+	ChannelSMS  Channel = "sms"  // ChannelSMS attempts come from sms-checker.
+	ChannelHTTP Channel = "http" // ChannelHTTP attempts are direct API calls.
+)
+
+// Outcome is the result of an attempt.
+type Outcome string
+
+const (
+	OutcomeOpened Outcome = "opened" // OutcomeOpened means the door was opened.
+	OutcomeDenied Outcome = "denied" // OutcomeDenied means the attempt was refused, see Entry.Reason.
+	OutcomeFailed Outcome = "failed" // OutcomeFailed means the attempt was allowed but opening failed, see Entry.Error.
+)
+
+// Entry is one opening attempt.
+type Entry struct {
+	Time     time.Time `json:"time"`               // Time is when the attempt was made.
+	Channel  Channel   `json:"channel"`            // Channel is how the attempt reached the service.
+	Door     string    `json:"door,omitempty"`     // Door is the name of the door to open.
+	Identity string    `json:"identity,omitempty"` // Identity is the resident or client name, empty if unknown.
+	Phone    string    `json:"phone,omitempty"`    // Phone is the sender number of SMS attempts.
+	IP       string    `json:"ip,omitempty"`       // IP is the client address of HTTP attempts.
+	Route    string    `json:"route,omitempty"`    // Route is the method and path of a request rejected before its handler.
+	Outcome  Outcome   `json:"outcome"`            // Outcome is the result of the attempt.
+	Reason   string    `json:"reason,omitempty"`   // Reason tells why the attempt was denied.
+	Error    string    `json:"error,omitempty"`    // Error is the stage, kind and status of the Rosdomofon error of a failed attempt.
+}
+
+// Filter selects entries. Zero fields match everything.
+type Filter struct {
+	From     time.Time // From is the first time included.
+	To       time.Time // To is the first time excluded.
+	Identity string    // Identity matches Entry.Identity exactly.
+	Outcome  Outcome   // Outcome matches Entry.Outcome.
+	Channel  Channel   // Channel matches Entry.Channel.
+	Door     string    // Door matches Entry.Door.
+	Limit    int       // Limit is the maximum number of entries returned, the newest ones.
+}
+
+// match reports whether the entry passes the filter, the time range aside.
+func (f Filter) match(entry Entry) bool {
+	return (f.Identity == "" || entry.Identity == f.Identity) &&
+		(f.Outcome == "" || entry.Outcome == f.Outcome) &&
+		(f.Channel == "" || entry.Channel == f.Channel) &&
+		(f.Door == "" || entry.Door == f.Door)
+}
+
+// Log is the audit log, backed by a bbolt file.
+type Log struct {
+	db *bolt.DB
+}
+
+func New(config *config.Config, lc fx.Lifecycle) (*Log, error) {
+	if dir := filepath.Dir(config.AuditFile); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create audit log directory %s: %w", dir, err)
+		}
+	}
+
+	log, err := Open(config.AuditFile)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return log.Close()
+		},
+	})
+
+	return log, nil
+}
+
+// Open opens or creates the audit log file at path.
+func Open(path string) (*Log, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{entriesBucket, noncesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create audit log bucket: %w", err)
+	}
+
+	return &Log{db: db}, nil
+}
+
+// Close closes the audit log file.
+func (l *Log) Close() error {
+	return l.db.Close()
+}
+
+// timeKey returns the key prefix of a time: big-endian Unix nanoseconds, which sort chronologically.
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
+
+// Add appends an entry. A zero Time is set to now.
+func (l *Log) Add(entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	err = l.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(entriesBucket)
+		sequence, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		// The sequence keeps entries of the same nanosecond apart
+		key := binary.BigEndian.AppendUint64(timeKey(entry.Time), sequence)
+		return bucket.Put(key, data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// Query returns the entries matching the filter, newest first.
+func (l *Log) Query(filter Filter) ([]Entry, error) {
+	entries := []Entry{}
+	err := l.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(entriesBucket).Cursor()
+
+		var key, value []byte
+		if filter.To.IsZero() {
+			key, value = cursor.Last()
+		} else if key, value = cursor.Seek(timeKey(filter.To)); key == nil {
+			key, value = cursor.Last()
+		} else {
+			key, value = cursor.Prev()
+		}
+
+		for ; key != nil; key, value = cursor.Prev() {
+			var entry Entry
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return fmt.Errorf("failed to decode audit entry %x: %w", key, err)
+			}
+			if !filter.To.IsZero() && !entry.Time.Before(filter.To) {
+				continue
+			}
+			if !filter.From.IsZero() && entry.Time.Before(filter.From) {
+				break
+			}
+			if !filter.match(entry) {
+				continue
+			}
+
+			entries = append(entries, entry)
+			if filter.Limit > 0 && len(entries) >= filter.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// UseNonce records the nonce of a signed request and reports whether it was not used before.
+// Nonces signed before expired are forgotten. The log is the nonce store of the signature verifier,
+// so a request captured before a restart cannot be replayed after it.
+func (l *Log) UseNonce(nonce string, signedAt, expired time.Time) (bool, error) {
+	fresh := false
+	err := l.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(noncesBucket)
+
+		cursor := bucket.Cursor()
+		for key, value := cursor.First(); key != nil; key, value = cursor.Next() {
+			if len(value) != 8 || int64(binary.BigEndian.Uint64(value)) < expired.UnixNano() {
+				if err := cursor.Delete(); err != nil {
+					return err
+				}
+			}
+		}
+
+		if bucket.Get([]byte(nonce)) != nil {
+			return nil
+		}
+		fresh = true
+		return bucket.Put([]byte(nonce), timeKey(signedAt))
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to record nonce: %w", err)
+	}
+	return fresh, nil
+}
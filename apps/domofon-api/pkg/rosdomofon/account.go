@@ -0,0 +1,158 @@
+package rosdomofon
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/imroc/req/v3"
+)
+
+// label is a value of the abonent API that comes either as a string or as a number, such as a house number.
+type label string
+
+func (l *label) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = label(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*l = label(n)
+	return nil
+}
+
+// Address is the address of a flat or a key as the mobile app shows it.
+type Address struct {
+	City   string `json:"city"`
+	Street struct {
+		Name string `json:"name"`
+	} `json:"street"`
+	House struct {
+		Number label `json:"number"`
+	} `json:"house"`
+	Entrance struct {
+		Number label `json:"number"`
+	} `json:"entrance"`
+}
+
+func (a Address) String() string {
+	parts := make([]string, 0, 4)
+	for _, part := range []string{a.City, a.Street.Name, string(a.House.Number)} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if a.Entrance.Number != "" {
+		parts = append(parts, "подъезд "+string(a.Entrance.Number))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Flat is a flat of the account with the adapter of its entrance.
+type Flat struct {
+	ID        int     `json:"id"`
+	Address   Address `json:"address"`
+	AdapterID label   `json:"adapterId"`
+}
+
+// Key is a key of the account: its ID is the KEY_ID a temporary key is created for.
+type Key struct {
+	ID        int     `json:"id"`
+	AdapterID label   `json:"adapterId"`
+	Address   Address `json:"address"`
+}
+
+// Entrance is an entrance, a gate or a barrier of the account: the intercom adapter serving it and the keys it opens.
+type Entrance struct {
+	Address   Address `json:"address"`
+	AdapterID label   `json:"adapterId"`
+	Keys      []Key   `json:"keys"`
+}
+
+// Account is what the account of REFRESH_TOKEN may open.
+type Account struct {
+	Addresses []Address  `json:"addresses"` // Addresses are the houses of the flats and keys.
+	Entrances []Entrance `json:"entrances"` // Entrances are the adapters of the flats and keys, with the keys of each.
+	Flats     []Flat     `json:"flats"`
+	Keys      []Key      `json:"keys"`
+}
+
+// Account lists the flats and the keys of the account from the abonent API used by the mobile app,
+// and groups them by address and by entrance adapter.
+func (d *Domofon) Account() (*Account, error) {
+	var account Account
+	if err := d.getAuthorized("/abonents-service/api/v2/abonents/flats", &account.Flats); err != nil {
+		return nil, err
+	}
+	if err := d.getAuthorized("/abonents-service/api/v2/abonents/keys", &account.Keys); err != nil {
+		return nil, err
+	}
+	account.group()
+	return &account, nil
+}
+
+// group fills the addresses and the entrances from the flats and the keys.
+// A flat without adapter is an entrance of its own; a key whose adapter serves no flat, such as a gate, is one too.
+func (a *Account) group() {
+	a.Addresses = make([]Address, 0)
+	a.Entrances = make([]Entrance, 0)
+
+	houses := make(map[string]bool)
+	addHouse := func(address Address) {
+		address.Entrance.Number = ""
+		if name := address.String(); name != "" && !houses[name] {
+			houses[name] = true
+			a.Addresses = append(a.Addresses, address)
+		}
+	}
+
+	entrances := make(map[string]int)
+	addEntrance := func(address Address, adapterID label) int {
+		id := string(adapterID)
+		if id == "" {
+			id = address.String()
+		}
+		if i, ok := entrances[id]; ok {
+			return i
+		}
+		entrances[id] = len(a.Entrances)
+		a.Entrances = append(a.Entrances, Entrance{Address: address, AdapterID: adapterID, Keys: make([]Key, 0)})
+		return len(a.Entrances) - 1
+	}
+
+	for _, flat := range a.Flats {
+		addHouse(flat.Address)
+		addEntrance(flat.Address, flat.AdapterID)
+	}
+	for _, key := range a.Keys {
+		addHouse(key.Address)
+		if key.AdapterID == "" {
+			continue
+		}
+		i := addEntrance(key.Address, key.AdapterID)
+		a.Entrances[i].Keys = append(a.Entrances[i].Keys, key)
+	}
+}
+
+// getAuthorized decodes the response of a GET of the abonent API into result, refreshing the access token when needed.
+func (d *Domofon) getAuthorized(path string, result any) error {
+	resp, err := d.sendAuthorized(func(token string) (*req.Response, error) {
+		return d.client.R().
+			SetHeader("Authorization", token).
+			SetSuccessResult(result).
+			Get(path)
+	})
+	if err != nil {
+		return newError(StageListAccount, 0, fmt.Errorf("failed to get %s: %w", path, withoutURL(err)))
+	}
+
+	if !resp.IsSuccessState() {
+		return newError(StageListAccount, resp.StatusCode, fmt.Errorf("get %s failed with status: %s", path, resp.Status))
+	}
+
+	return nil
+}
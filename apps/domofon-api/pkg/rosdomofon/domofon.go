@@ -1,21 +1,50 @@
 package rosdomofon
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
+	"sync"
 
 	"domofon-api.gg/config"
+	"domofon-api.gg/redact"
 
 	"github.com/imroc/req/v3"
 )
 
 type Domofon struct {
+	// mu guards the tokens, handlers call Rosdomofon concurrently
+	mu           sync.Mutex
 	accessToken  string
 	refreshToken string
 	baseURL      string
 	client       *req.Client
 }
 
+// requestError is a failed request without its URL, which may hold the activation token.
+type requestError struct {
+	op  string
+	err error
+}
+
+func (e *requestError) Error() string {
+	return e.op + ": " + e.err.Error()
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+// withoutURL returns err with the URL of a *url.Error left out, keeping its operation and cause,
+// so the activation token in the path or the query is never logged nor returned to clients.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	return &requestError{op: urlErr.Op, err: urlErr.Err}
+}
+
 type TokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	TokenType    string `json:"token_type"`
@@ -40,6 +69,54 @@ func NewDomofon(config *config.Config) *Domofon {
 	}
 }
 
+// token returns the access token, getting one first if there is none yet.
+func (d *Domofon) token() (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.accessToken == "" {
+		if err := d.refreshAccessToken(); err != nil {
+			return "", err
+		}
+	}
+	return d.accessToken, nil
+}
+
+// renewToken refreshes the access token rejected with 401. If another request already refreshed it meanwhile,
+// the new token is returned without refreshing again.
+func (d *Domofon) renewToken(rejected string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.accessToken == rejected {
+		if err := d.refreshAccessToken(); err != nil {
+			return "", err
+		}
+	}
+	return d.accessToken, nil
+}
+
+// sendAuthorized sends a request with the access token, refreshing the token and sending it again on 401.
+// Token errors are returned as *Error, errors of send as is.
+func (d *Domofon) sendAuthorized(send func(token string) (*req.Response, error)) (*req.Response, error) {
+	token, err := d.token()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := send(token)
+	if err != nil || resp.StatusCode != 401 {
+		return resp, err
+	}
+
+	token, err = d.renewToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return send(token)
+}
+
+// refreshAccessToken gets a new access token with the refresh token. d.mu must be held.
 func (d *Domofon) refreshAccessToken() error {
 	formData := map[string]string{
 		"grant_type":    "refresh_token",
@@ -54,15 +131,16 @@ func (d *Domofon) refreshAccessToken() error {
 		Post("/authserver-service/oauth/token")
 
 	if err != nil {
-		return fmt.Errorf("failed to refresh token: %w", err)
+		return newError(StageTokenRefresh, 0, fmt.Errorf("failed to refresh token: %w", withoutURL(err)))
 	}
 
 	if !resp.IsSuccessState() {
-		return fmt.Errorf("failed to refresh token, status: %s", resp.Status)
+		return newError(StageTokenRefresh, resp.StatusCode, fmt.Errorf("failed to refresh token, status: %s", resp.Status))
 	}
 
 	d.accessToken = "Bearer " + tokenResp.AccessToken
 	d.refreshToken = tokenResp.RefreshToken
+	redact.Add(tokenResp.AccessToken, tokenResp.RefreshToken)
 	return nil
 }
 
@@ -81,48 +159,20 @@ func (d *Domofon) CreateTemporaryKey(KeyID int) (string, error) {
 		WorkingPeriod:    12,
 	}
 
-	if d.accessToken == "" {
-		if err := d.refreshAccessToken(); err != nil {
-			return "", fmt.Errorf("failed to refresh access token: %w", err)
-		}
-	}
-
-	if d.accessToken == "" {
-		if err := d.refreshAccessToken(); err != nil {
-			return "", fmt.Errorf("failed to refresh access token: %w", err)
-		}
-	}
-
-	sendRequest := func() (*req.Response, error) {
+	resp, err := d.sendAuthorized(func(token string) (*req.Response, error) {
 		return d.client.R().
-			SetHeader("Authorization", d.accessToken).
+			SetHeader("Authorization", token).
 			SetHeader("Content-Type", "application/json").
 			SetBody(body).
 			SetSuccessResult(&result).
 			Post("/rdas-service/api/v1/temporary_keys")
-	}
-
-	// First attempt
-	resp, err := sendRequest()
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to create temporary key: %w", err)
-	}
-
-	// If 401 Unauthorized, try to refresh token and retry
-	if resp.StatusCode == 401 {
-		if err := d.refreshAccessToken(); err != nil {
-			return "", fmt.Errorf("failed to refresh access token: %w", err)
-		}
-
-		// Retry after token refresh
-		resp, err = sendRequest()
-		if err != nil {
-			return "", fmt.Errorf("failed to create temporary key after token refresh: %w", err)
-		}
+		return "", newError(StageCreateKey, 0, fmt.Errorf("failed to create temporary key: %w", withoutURL(err)))
 	}
 
 	if !resp.IsSuccessState() {
-		return "", fmt.Errorf("request failed with status: %s", resp.Status)
+		return "", newError(StageCreateKey, resp.StatusCode, fmt.Errorf("request failed with status: %s", resp.Status))
 	}
 
 	return result.ActivationLink, nil
@@ -135,13 +185,13 @@ func (d *Domofon) ActivateKey(activationLink string) error {
 	// Parse the URL to extract the token
 	parsedURL, err := url.Parse(activationLink)
 	if err != nil {
-		return fmt.Errorf("invalid activation link: %w", err)
+		return newError(StageActivateKey, 0, fmt.Errorf("invalid activation link: %w", withoutURL(err)))
 	}
 
 	// Extract the token from query parameters
 	token := parsedURL.Query().Get("token")
 	if token == "" {
-		return fmt.Errorf("no token found in activation link")
+		return newError(StageActivateKey, 0, fmt.Errorf("no token found in activation link"))
 	}
 
 	// Build the activation URL
@@ -152,12 +202,12 @@ func (d *Domofon) ActivateKey(activationLink string) error {
 		Post(activationURL)
 
 	if err != nil {
-		return fmt.Errorf("failed to activate key: %w", err)
+		return newError(StageActivateKey, 0, fmt.Errorf("failed to activate key: %w", withoutURL(err)))
 	}
 
 	// Check if we got a 204 No Content response
 	if resp.StatusCode != 204 {
-		return fmt.Errorf("activation failed with status: %s", resp.Status)
+		return newError(StageActivateKey, resp.StatusCode, fmt.Errorf("activation failed with status: %s", resp.Status))
 	}
 
 	return nil
@@ -0,0 +1,92 @@
+package rosdomofon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Stage is the step of the call to Rosdomofon that failed.
+type Stage string
+
+const (
+	StageTokenRefresh Stage = "token_refresh" // StageTokenRefresh is the refresh of the access token.
+	StageCreateKey    Stage = "create_key"    // StageCreateKey is the creation of the temporary key.
+	StageActivateKey  Stage = "activate_key"  // StageActivateKey is the activation of the temporary key.
+	StageListAccount  Stage = "list_account"  // StageListAccount is the listing of the flats and keys of the account.
+)
+
+// Kind is what went wrong in a failed call to Rosdomofon.
+type Kind string
+
+const (
+	KindTransport Kind = "transport" // KindTransport means Rosdomofon could not be reached or did not answer.
+	KindStatus    Kind = "status"    // KindStatus means Rosdomofon answered with an error status.
+	KindResponse  Kind = "response"  // KindResponse means the answer or the data of the call was unusable, such as an activation link without token.
+)
+
+// Error is a failed call to Rosdomofon.
+type Error struct {
+	Stage      Stage // Stage is the step that failed.
+	Kind       Kind  // Kind is what went wrong.
+	StatusCode int   // StatusCode is the HTTP status returned by Rosdomofon, 0 if no response was received.
+	Err        error // Err is the underlying error.
+}
+
+func (e *Error) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("rosdomofon %s failed with status %d: %v", e.Stage, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("rosdomofon %s failed: %v", e.Stage, e.Err)
+}
+
+// Summary describes the failure by its stage, kind and status only. Unlike Error it leaves the underlying error out,
+// which may hold request URLs, so it may be stored and shown to clients.
+func (e *Error) Summary() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s %s %d", e.Stage, e.Kind, e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s", e.Stage, e.Kind)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Temporary reports whether retrying later may succeed: Rosdomofon was unreachable, overloaded or failing.
+// Other errors, such as a revoked refresh token, an unknown key or an unusable answer, will not go away by retrying.
+func (e *Error) Temporary() bool {
+	switch e.Kind {
+	case KindTransport:
+		return true
+	case KindStatus:
+		return e.StatusCode == 429 || e.StatusCode >= 500
+	default:
+		return false
+	}
+}
+
+// Timeout reports whether Rosdomofon did not answer in time.
+func (e *Error) Timeout() bool {
+	var netErr net.Error
+	return errors.As(e.Err, &netErr) && netErr.Timeout()
+}
+
+// newError returns an *Error of the stage. A nested *Error, such as a failed token refresh, is returned as is.
+// The kind is told by the error: a status code, a network error, or else an unusable answer.
+func newError(stage Stage, statusCode int, err error) error {
+	var nested *Error
+	if errors.As(err, &nested) {
+		return nested
+	}
+
+	kind := KindResponse
+	var netErr net.Error
+	switch {
+	case statusCode != 0:
+		kind = KindStatus
+	case errors.As(err, &netErr):
+		kind = KindTransport
+	}
+	return &Error{Stage: stage, Kind: kind, StatusCode: statusCode, Err: err}
+}
@@ -2,8 +2,13 @@ package app
 
 import (
 	"domofon-api/connections/modem"
+	"domofon-api/connections/store"
 	checker "domofon-api/internal"
+	"domofon-api/pkg/domofonClient"
+	"domofon-api/pkg/limiter"
+	"domofon-api/pkg/residents"
 	"domofon-api/pkg/smsPoller"
+	"domofon-api/pkg/smsReply"
 
 	"go.uber.org/fx"
 )
@@ -11,7 +16,12 @@ import (
 var App = fx.Options(
 	fx.Provide(
 		modem.New,
+		store.New,
 		smsPoller.New,
+		residents.New,
+		smsReply.New,
+		limiter.New,
+		domofonClient.New,
 	),
 	fx.Invoke(
 		checker.Start,
@@ -2,20 +2,41 @@ package main
 
 import (
 	"domofon-api/app"
+	"domofon-api/internal/cli"
 	"fmt"
+	"os"
+	_ "time/tzdata" // the runner image has no zoneinfo, MODEM_TIMEZONE needs it
 
 	"domofon-api.gg/config"
+	"domofon-api.gg/redact"
 	"go.uber.org/fx"
+	"go.uber.org/fx/fxevent"
 )
 
 func main() {
+	if handled, err := cli.Run(os.Args[1:]); handled {
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := redact.Install(); err != nil {
+		fmt.Printf("Failed to install log redaction: %v\n", err)
+		return
+	}
+	defer redact.Close()
+
 	cfg, err := config.Load()
 	if err != nil {
 		fmt.Printf("Failed to load config       : %v\n", err)
 		return
 	}
+	redact.Add(cfg.Secrets()...)
 
 	fx.New(
+		fx.WithLogger(func() fxevent.Logger { return &fxevent.ConsoleLogger{W: redact.Stderr()} }),
 		fx.Supply(cfg),
 		app.App,
 	).Run()
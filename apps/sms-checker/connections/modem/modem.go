@@ -2,43 +2,60 @@ package modem
 
 import (
 	"domofon-api/pkg/huaweimodem"
+	"errors"
 	"log"
 	"time"
 
 	"domofon-api.gg/config"
+	"domofon-api.gg/redact"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func New(config *config.Config) *huaweimodem.Device {
-	logger, _ := zap.NewProduction()
+	// The modem logs go through the redactor synchronously, zap exits the process on Fatal
+	logger := zap.New(zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(redact.Stderr()),
+		zap.InfoLevel,
+	))
 	defer logger.Sync()
 	sugar := logger.Sugar()
 
-	modem, err := huaweimodem.NewDevice(sugar, config.ModemUrl, "", "")
+	modem, err := huaweimodem.NewDevice(sugar, config.ModemUrl, config.ModemUser, config.ModemPassword)
 	if err != nil {
 		log.Fatalf("Failed to create modem: %v", err)
 	}
 
+	location, err := time.LoadLocation(config.ModemTimezone)
+	if err != nil {
+		log.Fatalf("Invalid MODEM_TIMEZONE %q: %v", config.ModemTimezone, err)
+	}
+	modem.SetLocation(location)
+
 	maxAttempts := 10
 	retryInterval := 5 * time.Second
 
-	var lastErr error
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		err = modem.Login()
 		if err == nil {
 			break
 		}
 
-		lastErr = err
+		// Retrying with wrong credentials only gets the modem locked out
+		if errors.Is(err, huaweimodem.ErrWrongUsername) || errors.Is(err, huaweimodem.ErrWrongPassword) || errors.Is(err, huaweimodem.ErrLockedOut) {
+			log.Fatalf("Failed to login, check MODEM_USER and MODEM_PASSWORD: %v", err)
+		}
+
 		if attempt < maxAttempts {
 			log.Printf("Login attempt %d/%d failed, retrying in %v: %v", attempt, maxAttempts, retryInterval, err)
 			time.Sleep(retryInterval)
 		}
 	}
 
-	if lastErr != nil {
-		log.Fatalf("Failed to login after %d attempts: %v", maxAttempts, lastErr)
+	if err != nil {
+		log.Fatalf("Failed to login after %d attempts: %v", maxAttempts, err)
 	}
 
 	return modem
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"domofon-api/pkg/messageStore"
+	"log"
+	"os"
+	"path/filepath"
+
+	"domofon-api.gg/config"
+
+	"go.uber.org/fx"
+)
+
+func New(config *config.Config, lc fx.Lifecycle) *messageStore.Store {
+	if dir := filepath.Dir(config.StoreFile); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Fatalf("Failed to create store directory: %v", err)
+		}
+	}
+
+	store, err := messageStore.Open(config.StoreFile)
+	if err != nil {
+		log.Fatalf("Failed to open message store: %v", err)
+	}
+
+	imported, err := store.ImportLegacyFile(config.LastSmsFile)
+	if err != nil {
+		log.Fatalf("Failed to import %s: %v", config.LastSmsFile, err)
+	}
+	if imported > 0 {
+		log.Printf("Imported %d SMS ids from %s", imported, config.LastSmsFile)
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return store.Close()
+		},
+	})
+
+	return store
+}
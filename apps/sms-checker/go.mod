@@ -2,11 +2,21 @@ module domofon-api
 
 replace domofon-api.gg/config => ../../pkg/config
 
+replace domofon-api.gg/redact => ../../pkg/redact
+
+replace domofon-api.gg/signing => ../../pkg/signing
+
 require (
 	domofon-api.gg/config v0.0.0-00010101000000-000000000000
+	domofon-api.gg/redact v0.0.0-00010101000000-000000000000
+	domofon-api.gg/signing v0.0.0-00010101000000-000000000000
 	github.com/imroc/req/v3 v3.53.0
+	go.etcd.io/bbolt v1.4.3
 	go.uber.org/fx v1.24.0
 	go.uber.org/zap v1.26.0
+	golang.org/x/crypto v0.39.0
+	golang.org/x/text v0.26.0
+	rsc.io/qr v0.2.0
 )
 
 require (
@@ -36,12 +46,10 @@ require (
 	go.uber.org/dig v1.19.0 // indirect
 	go.uber.org/mock v0.5.2 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
-	golang.org/x/crypto v0.39.0 // indirect
 	golang.org/x/mod v0.25.0 // indirect
 	golang.org/x/net v0.41.0 // indirect
 	golang.org/x/sync v0.15.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
-	golang.org/x/text v0.26.0 // indirect
 	golang.org/x/tools v0.34.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,296 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"domofon-api/pkg/limiter"
+	"domofon-api/pkg/messageStore"
+	"domofon-api/pkg/residents"
+	"domofon-api/pkg/smsCommand"
+	"domofon-api/pkg/smsPoller"
+	"domofon-api/pkg/smsReply"
+)
+
+// adminHandler executes an admin command and returns the answer sent back to the admin.
+type adminHandler = func(command *smsCommand.Command) (string, error)
+
+// adminHandlers returns the handlers of the admin commands, by command name.
+func (c *checker) adminHandlers() map[string]adminHandler {
+	return map[string]adminHandler{
+		"add":     c.adminAdd,
+		"remove":  c.adminRemove,
+		"block":   c.adminBlock(false),
+		"unblock": c.adminBlock(true),
+		"list":    c.adminList,
+		"status":  c.adminStatus,
+		"pause":   c.adminPause,
+		"resume":  c.adminResume,
+	}
+}
+
+// isAdmin reports whether the phone number is one of ADMIN_PHONES.
+func (c *checker) isAdmin(phone string) bool {
+	normalized, ok := residents.NormalizePhone(phone)
+	if !ok {
+		return false
+	}
+	return slices.ContainsFunc(c.config.AdminPhones, func(admin string) bool {
+		adminPhone, ok := residents.NormalizePhone(admin)
+		return ok && adminPhone == normalized
+	})
+}
+
+// authorizeAdmin checks the PIN or one-time code sent with an admin command, the sender number alone is easily spoofed.
+// The admin must be an enabled resident with a PIN or a TOTP secret. Wrong codes count towards the lockout of the number.
+func (c *checker) authorizeAdmin(sms smsPoller.SMS, command *smsCommand.Command) (*residents.Resident, error) {
+	resident, err := c.registry.Lookup(sms.Phone)
+	if err != nil {
+		return nil, err
+	}
+	if resident == nil || !resident.Enabled {
+		return nil, fmt.Errorf("admin number is not an enabled resident")
+	}
+	if !resident.HasPIN() && !resident.HasTOTP() {
+		return resident, fmt.Errorf("admin %s has no PIN or TOTP secret", resident.Name)
+	}
+
+	// Admin commands are not opens, the hourly cap does not apply
+	phone := limitKey(sms.Phone)
+	if err := c.limiter.Check(phone, time.Now()); err != nil && !errors.Is(err, limiter.ErrTooManyOpens) {
+		return resident, err
+	}
+
+	if err := c.authorize(resident, command.Code, sms.Date); err != nil {
+		if err := c.limiter.Failure(phone, time.Now()); err != nil {
+			log.Println(err)
+		}
+		return resident, err
+	}
+
+	return resident, nil
+}
+
+// admin runs an admin command sent from one of ADMIN_PHONES with the PIN or one-time code of the admin,
+// answers the admin and audits the command. Admin commands from other numbers are ignored as spam.
+func (c *checker) admin(sms smsPoller.SMS, command *smsCommand.Command) smsPoller.Outcome {
+	if !c.isAdmin(sms.Phone) {
+		log.Printf("Admin command %s from non-admin number %s ignored\n", command.Name, sms.Phone)
+		return smsPoller.Outcome{Decision: smsPoller.DecisionSpam}
+	}
+
+	resident, err := c.authorizeAdmin(sms, command)
+	if err != nil {
+		log.Printf("Admin command %s from %s not authorized: %v\n", command.Name, sms.Phone, err)
+		if err := c.store.AddAudit(messageStore.AuditEntry{
+			Time:    time.Now(),
+			Phone:   sms.Phone,
+			Command: command.Name,
+			Args:    command.Args,
+			Error:   "not authorized: " + err.Error(),
+		}); err != nil {
+			log.Println(err)
+		}
+		if errors.Is(err, limiter.ErrLockedOut) {
+			c.reply(sms, resident, smsReply.ResultLocked)
+		} else {
+			c.reply(sms, resident, smsReply.ResultWrongCode)
+		}
+		return smsPoller.Outcome{Decision: smsPoller.DecisionCommand}
+	}
+
+	handler, ok := c.adminHandlers()[command.Name]
+	if !ok {
+		log.Printf("No handler for admin command %s\n", command.Name)
+		return smsPoller.Outcome{Decision: smsPoller.DecisionCommand}
+	}
+
+	answer, err := handler(command)
+	entry := messageStore.AuditEntry{
+		Time:    time.Now(),
+		Phone:   sms.Phone,
+		Command: command.Name,
+		Args:    command.Args,
+		Result:  answer,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		answer = "Ошибка: " + err.Error()
+		entry.Result = answer
+	}
+	log.Printf("Admin %s: %s %v -> %s\n", sms.Phone, command.Name, command.Args, answer)
+
+	if err := c.store.AddAudit(entry); err != nil {
+		log.Println(err)
+	}
+	if err := c.replier.Send(sms.Phone, answer); err != nil {
+		log.Printf("Failed to answer admin %s: %v\n", sms.Phone, err)
+	}
+
+	return smsPoller.Outcome{Decision: smsPoller.DecisionCommand, Resident: resident.Name}
+}
+
+// usage returns the error answered to an admin who got the arguments of a command wrong.
+func (c *checker) usage(result smsReply.Result) error {
+	return errors.New(c.replier.Text(result, ""))
+}
+
+// adminAdd handles "add <phone> <name>".
+func (c *checker) adminAdd(command *smsCommand.Command) (string, error) {
+	if len(command.Args) < 2 {
+		return "", c.usage(smsReply.ResultUsageAdd)
+	}
+
+	resident, err := c.registry.AddPhone(strings.Join(command.Args[1:], " "), command.Args[0])
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Добавлен %s: %s", resident.Name, strings.Join(resident.Phones, ", ")), nil
+}
+
+// adminRemove handles "remove <phone>".
+func (c *checker) adminRemove(command *smsCommand.Command) (string, error) {
+	if len(command.Args) != 1 {
+		return "", c.usage(smsReply.ResultUsageRemove)
+	}
+
+	resident, err := c.registry.RemovePhone(command.Args[0])
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Номер %s удален у %s", command.Args[0], resident.Name), nil
+}
+
+// adminBlock returns the handler of "block <phone or name>", or of "unblock" if enabled is set.
+func (c *checker) adminBlock(enabled bool) adminHandler {
+	return func(command *smsCommand.Command) (string, error) {
+		if len(command.Args) == 0 {
+			if enabled {
+				return "", c.usage(smsReply.ResultUsageUnblock)
+			}
+			return "", c.usage(smsReply.ResultUsageBlock)
+		}
+
+		resident, err := c.registry.SetEnabled(strings.Join(command.Args, " "), enabled)
+		if err != nil {
+			return "", err
+		}
+		if enabled {
+			return fmt.Sprintf("%s разблокирован", resident.Name), nil
+		}
+		return fmt.Sprintf("%s заблокирован", resident.Name), nil
+	}
+}
+
+// adminList handles "list".
+func (c *checker) adminList(*smsCommand.Command) (string, error) {
+	all, err := c.registry.All()
+	if err != nil {
+		return "", err
+	}
+	if len(all) == 0 {
+		return "Список жильцов пуст", nil
+	}
+
+	lines := make([]string, 0, len(all))
+	for _, resident := range all {
+		line := resident.Name + " " + strings.Join(resident.Phones, ",")
+		if !resident.Enabled {
+			line += " (блок)"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// adminStatus handles "status".
+func (c *checker) adminStatus(*smsCommand.Command) (string, error) {
+	var lines []string
+
+	pausedUntil, err := c.store.PausedUntil()
+	if err != nil {
+		return "", err
+	}
+	if time.Now().Before(pausedUntil) {
+		lines = append(lines, "Пауза до "+pausedUntil.Format("02.01 15:04"))
+	} else {
+		lines = append(lines, "Открытие работает")
+	}
+
+	all, err := c.registry.All()
+	if err != nil {
+		return "", err
+	}
+	lines = append(lines, fmt.Sprintf("Жильцов: %d", len(all)))
+
+	status := c.poller.Status()
+	if !status.ClockCheckedAt.IsZero() {
+		clock := fmt.Sprintf("Часы модема: %+ds", int(status.ClockDrift.Seconds()))
+		if status.ClockDrifting {
+			clock += " (расходятся)"
+		}
+		lines = append(lines, clock)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// adminPause handles "pause <duration>", such as "pause 2h", "pause 30m" or "pause 1d".
+func (c *checker) adminPause(command *smsCommand.Command) (string, error) {
+	if len(command.Args) != 1 {
+		return "", c.usage(smsReply.ResultUsagePause)
+	}
+
+	duration, err := parsePauseDuration(command.Args[0])
+	if err != nil {
+		return "", err
+	}
+
+	until := time.Now().Add(duration)
+	if err := c.store.SetPausedUntil(until); err != nil {
+		return "", err
+	}
+	return "Открытие по смс отключено до " + until.Format("02.01 15:04"), nil
+}
+
+// adminResume handles "resume".
+func (c *checker) adminResume(*smsCommand.Command) (string, error) {
+	if err := c.store.SetPausedUntil(time.Time{}); err != nil {
+		return "", err
+	}
+	return "Открытие по смс включено", nil
+}
+
+// parsePauseDuration parses a Go duration ("2h", "30m"), with days ("1d") in addition.
+func parsePauseDuration(value string) (time.Duration, error) {
+	value = strings.ToLower(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		count, err := strconv.Atoi(days)
+		if err != nil || count <= 0 {
+			return 0, fmt.Errorf("неверная длительность %q", value)
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("неверная длительность %q", value)
+	}
+	return duration, nil
+}
+
+// paused reports whether remote opening is paused by an admin.
+// If the pause cannot be read, opening is considered paused: an admin pause must not be lost silently.
+func (c *checker) paused() bool {
+	until, err := c.store.PausedUntil()
+	if err != nil {
+		log.Printf("Failed to read the pause, treating remote opening as paused: %v\n", err)
+		return true
+	}
+	return time.Now().Before(until)
+}
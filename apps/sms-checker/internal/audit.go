@@ -0,0 +1,15 @@
+package checker
+
+import (
+	"log"
+
+	"domofon-api/pkg/smsPoller"
+)
+
+// reportDenied records in the audit log of domofon-api an SMS attempt refused before the door was asked to open.
+// Allowed attempts are recorded by domofon-api itself when open is called.
+func (c *checker) reportDenied(sms smsPoller.SMS, door, identity, reason string) {
+	if err := c.domofon.ReportDenied(door, identity, sms.Phone, reason); err != nil {
+		log.Printf("Failed to audit denied attempt of %s: %v\n", sms.Phone, err)
+	}
+}
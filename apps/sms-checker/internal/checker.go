@@ -1,44 +1,259 @@
 package checker
 
 import (
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"log"
-	"strings"
+	"time"
 
+	"domofon-api/pkg/domofonClient"
+	"domofon-api/pkg/limiter"
+	"domofon-api/pkg/messageStore"
+	"domofon-api/pkg/residents"
+	"domofon-api/pkg/smsCommand"
 	"domofon-api/pkg/smsPoller"
+	"domofon-api/pkg/smsReply"
+	"domofon-api/pkg/totp"
 
 	"domofon-api.gg/config"
-	"github.com/imroc/req/v3"
 )
 
-func Start(poller *smsPoller.SMSPoller, config *config.Config) {
-	poller.Start(func(sms smsPoller.SMS) {
-		fmt.Println("NewSMS FOR open", sms)
+type checker struct {
+	poller   *smsPoller.SMSPoller
+	config   *config.Config
+	registry *residents.Registry
+	store    *messageStore.Store
+	replier  *smsReply.Replier
+	limiter  *limiter.Limiter
+	domofon  *domofonClient.Client
+	parser   *smsCommand.Parser
+	handlers map[string]commandHandler
+}
+
+// commandHandler executes a parsed command sent by SMS.
+type commandHandler = func(sms smsPoller.SMS, command *smsCommand.Command) smsPoller.Outcome
+
+func Start(poller *smsPoller.SMSPoller, config *config.Config, registry *residents.Registry, store *messageStore.Store, replier *smsReply.Replier, limiter *limiter.Limiter, domofon *domofonClient.Client) {
+	if config.AllowAnySender {
+		log.Println("ALLOW_ANY_SENDER is set, the phone allowlist is disabled")
+	} else if empty, err := registry.Empty(); err == nil && empty {
+		log.Println("No residents configured, every SMS is rejected until one is added")
+	}
+
+	c := &checker{
+		poller:   poller,
+		config:   config,
+		registry: registry,
+		store:    store,
+		replier:  replier,
+		limiter:  limiter,
+		domofon:  domofon,
+		parser:   smsCommand.NewParser(smsCommand.Commands, doorNames(config)),
+	}
+	c.handlers = map[string]commandHandler{
+		"open": c.open,
+	}
+
+	poller.Start(c.handle)
+}
+
+func (c *checker) handle(sms smsPoller.SMS) smsPoller.Outcome {
+	log.Printf("NewSMS %d from %s\n", sms.Id, sms.Phone)
+
+	command, err := c.parser.Parse(sms.Content)
+	if err != nil {
+		log.Printf("SMS %d is not a command\n", sms.Id)
+		return smsPoller.Outcome{Decision: smsPoller.DecisionSpam}
+	}
+
+	if command.Admin {
+		return c.admin(sms, command)
+	}
+
+	handler, ok := c.handlers[command.Name]
+	if !ok {
+		log.Printf("No handler for command %s\n", command.Name)
+		return smsPoller.Outcome{Decision: smsPoller.DecisionCommand}
+	}
+
+	return handler(sms, command)
+}
+
+// doorNames maps every name and alias of the configured doors to the door name.
+func doorNames(config *config.Config) map[string]string {
+	names := make(map[string]string)
+	for _, door := range config.AllDoors() {
+		names[door.Name] = door.Name
+		for _, alias := range door.Aliases {
+			names[alias] = door.Name
+		}
+	}
+	return names
+}
+
+// open opens the door named in the command, or the primary door, for an allowlisted sender with a valid code.
+func (c *checker) open(sms smsPoller.SMS, command *smsCommand.Command) smsPoller.Outcome {
+	outcome := smsPoller.Outcome{Decision: smsPoller.DecisionCommand}
+
+	door := c.config.PrimaryDoor()
+	if command.Door != "" {
+		door, _ = c.config.FindDoor(command.Door)
+	}
+
+	resident, err := c.allowedSender(sms.Phone)
+	if err != nil {
+		log.Printf("Sender %s rejected: %v\n", sms.Phone, err)
+		c.reply(sms, nil, smsReply.ResultNotAllowed)
+		c.reportDenied(sms, door.Name, "", err.Error())
+		return outcome
+	}
+	if resident != nil {
+		outcome.Resident = resident.Name
+	}
+
+	if !door.Allows(outcome.Resident) {
+		log.Printf("Sender %s may not open door %s\n", sms.Phone, door.Name)
+		c.reply(sms, resident, smsReply.ResultNotAllowed)
+		c.reportDenied(sms, door.Name, outcome.Resident, "door not allowed")
+		return outcome
+	}
 
-		if !strings.Contains(sms.Content, "domofon") {
-			fmt.Println("Not domofon text")
-			return
+	if c.paused() {
+		log.Printf("Remote opening is paused, ignoring %s\n", sms.Phone)
+		c.reply(sms, resident, smsReply.ResultPaused)
+		c.reportDenied(sms, door.Name, outcome.Resident, "paused")
+		return outcome
+	}
+
+	phone := limitKey(sms.Phone)
+	if err := c.limiter.Check(phone, time.Now()); err != nil {
+		log.Printf("Sender %s limited: %v\n", sms.Phone, err)
+		switch {
+		case errors.Is(err, limiter.ErrLockedOut):
+			c.reply(sms, resident, smsReply.ResultLocked)
+		case errors.Is(err, limiter.ErrTooManyOpens):
+			c.reply(sms, resident, smsReply.ResultTooManyOpens)
 		}
-		if !strings.Contains(sms.Content, config.ProtectionCode) {
-			fmt.Println("Not protection code")
-			return
+		c.reportDenied(sms, door.Name, outcome.Resident, err.Error())
+		return outcome
+	}
+
+	if err := c.authorize(resident, command.Code, sms.Date); err != nil {
+		log.Printf("Sender %s not authorized: %v\n", sms.Phone, err)
+		if err := c.limiter.Failure(phone, time.Now()); err != nil {
+			log.Println(err)
 		}
+		c.reply(sms, resident, smsReply.ResultWrongCode)
+		c.reportDenied(sms, door.Name, outcome.Resident, err.Error())
+		return outcome
+	}
+
+	if err := c.domofon.Open(door.Name, outcome.Resident, sms.Phone); err != nil {
+		log.Printf("Error opening door %s: %v\n", door.Name, err)
+		var apiErr *domofonClient.APIError
+		if errors.As(err, &apiErr) && !apiErr.Retryable() {
+			log.Println("Rosdomofon refused the request, check REFRESH_TOKEN and KEY_ID")
+		}
+		c.reply(sms, resident, smsReply.ResultUnavailable)
+		return outcome
+	}
+
+	if resident != nil {
+		log.Printf("Door %s opened by resident %s\n", door.Name, resident.Name)
+	}
+	if err := c.limiter.Success(phone, time.Now()); err != nil {
+		log.Println(err)
+	}
+	c.reply(sms, resident, smsReply.ResultOpened)
+	return outcome
+}
 
-		// Create a new request client
-		client := req.C()
+// limitKey returns the key the attempts of a sender are counted under: its E.164 number if it has one.
+func limitKey(phone string) string {
+	if normalized, ok := residents.NormalizePhone(phone); ok {
+		return normalized
+	}
+	return phone
+}
 
-		// Make GET request with query parameters
-		resp, err := client.R().
-			SetQueryParam("code", config.SecretKey).
-			Get(fmt.Sprintf("http://domofonapi:%d/api/open", config.HttpPort))
+// reply tells the sender about the result of the command.
+// With the allowlist enforced, senders without a resident are unknown numbers.
+func (c *checker) reply(sms smsPoller.SMS, resident *residents.Resident, result smsReply.Result) {
+	name := ""
+	known := resident != nil || c.config.AllowAnySender
+	if resident != nil {
+		name = resident.Name
+	}
 
-		if err != nil {
-			log.Printf("Error making request: %v\n", err)
-			return
+	c.replier.Reply(sms.Phone, known, result, name)
+}
+
+// allowedSender returns the enabled resident owning the phone number.
+// With ALLOW_ANY_SENDER set the allowlist is not enforced and nil is returned without error for unknown numbers.
+// Otherwise unknown numbers are rejected, even when no resident is defined at all, such as after the last one was removed.
+func (c *checker) allowedSender(phone string) (*residents.Resident, error) {
+	resident, err := c.registry.Lookup(phone)
+	if err != nil {
+		return nil, err
+	}
+	if resident == nil {
+		if c.config.AllowAnySender {
+			return nil, nil
 		}
+		return nil, fmt.Errorf("not in the residents list")
+	}
+	if !resident.Enabled {
+		return nil, fmt.Errorf("resident %s is disabled", resident.Name)
+	}
+
+	return resident, nil
+}
+
+// authorize checks the code given in the command, sentAt is the date of the SMS: one-time codes are checked against it.
+// A resident enrolled for TOTP must send the current one-time code, a resident with an own PIN must send it;
+// anyone else must send the shared PROTECTION_CODE, unless REQUIRE_PROTECTION_CODE is off for allowlisted residents.
+func (c *checker) authorize(resident *residents.Resident, code string, sentAt time.Time) error {
+	if resident != nil && resident.HasTOTP() {
+		return c.checkTOTP(resident, code, sentAt)
+	}
+
+	if resident != nil && resident.HasPIN() {
+		if !resident.CheckPIN(code) {
+			return fmt.Errorf("wrong PIN")
+		}
+		return nil
+	}
+
+	if resident != nil && !c.config.RequireProtectionCode {
+		return nil
+	}
+	if code == "" || subtle.ConstantTimeCompare([]byte(code), []byte(c.config.ProtectionCode)) != 1 {
+		return fmt.Errorf("wrong protection code")
+	}
+
+	return nil
+}
+
+// checkTOTP accepts a valid one-time code of the resident within TOTP_DRIFT steps of sentAt that was not used before.
+// The code is checked at the date of the SMS rather than now, so an SMS delivered or polled late is accepted
+// as long as SMS_ALIVE_TIME accepts it; TOTP_DRIFT absorbs the skew between the phone and the modem clocks.
+func (c *checker) checkTOTP(resident *residents.Resident, code string, sentAt time.Time) error {
+	counter, ok, err := totp.Validate(resident.Totp, code, sentAt, c.config.TotpDrift)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("wrong one-time code")
+	}
+
+	accepted, err := c.store.UseTOTPCounter(resident.Name, counter)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return fmt.Errorf("one-time code already used")
+	}
 
-		// Log response status and body
-		log.Printf("Response status: %s\n", resp.Status)
-		log.Printf("Response body: %s\n", resp.String())
-	})
+	return nil
 }
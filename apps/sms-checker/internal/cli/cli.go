@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"domofon-api/pkg/messageStore"
+	"domofon-api/pkg/residents"
+	"domofon-api/pkg/totp"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"domofon-api.gg/config"
+	bolt "go.etcd.io/bbolt"
+	"rsc.io/qr"
+)
+
+// totpIssuer is the issuer shown by authenticator apps next to the resident's name.
+const totpIssuer = "Domofon"
+
+// Run executes a command line subcommand. It returns false if args do not name a known subcommand.
+func Run(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch args[0] {
+	case "pin-hash":
+		return true, pinHash(args[1:])
+	case "totp-enroll":
+		return true, totpEnroll(args[1:])
+	case "admin-log":
+		return true, adminLog(args[1:])
+	default:
+		return false, nil
+	}
+}
+
+// pinHash prints the bcrypt hash of a PIN, to be put in PIN_HASH of a resident.
+func pinHash(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: pin-hash <PIN>")
+	}
+
+	hash, err := residents.HashPIN(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to hash PIN: %w", err)
+	}
+
+	fmt.Println(hash)
+	return nil
+}
+
+// totpEnroll generates a TOTP secret for a resident and prints it with its otpauth URI and QR code.
+func totpEnroll(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: totp-enroll <resident name>")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return err
+	}
+	uri := totp.URI(secret, totpIssuer, args[0])
+
+	code, err := qr.Encode(uri, qr.M)
+	if err != nil {
+		return fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	fmt.Printf("TOTP_SECRET: %q\n\n", secret)
+	fmt.Printf("%s\n\n", uri)
+	fmt.Print(renderQR(code))
+	return nil
+}
+
+// adminLog prints the last admin commands from the admin audit log of STORE_FILE, newest first.
+// The store is locked by the running service, which must be stopped first.
+func adminLog(args []string) error {
+	limit := 20
+	if len(args) > 1 {
+		return fmt.Errorf("usage: admin-log [count]")
+	}
+	if len(args) == 1 {
+		count, err := strconv.Atoi(args[0])
+		if err != nil || count < 0 {
+			return fmt.Errorf("usage: admin-log [count]")
+		}
+		limit = count
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	store, err := messageStore.Open(cfg.StoreFile)
+	if errors.Is(err, bolt.ErrTimeout) {
+		return fmt.Errorf("%s is in use, stop sms-checker first", cfg.StoreFile)
+	}
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	entries, err := store.ListAudit(limit)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		line := fmt.Sprintf("%s  %s  %s", entry.Time.Format(time.DateTime), entry.Phone, strings.Join(append([]string{entry.Command}, entry.Args...), " "))
+		if entry.Error != "" {
+			line += "  error: " + entry.Error
+		} else {
+			line += "  -> " + entry.Result
+		}
+		fmt.Println(strings.ReplaceAll(line, "\n", "; "))
+	}
+	return nil
+}
+
+// renderQR draws a QR code with half block characters, two modules per line,
+// light on dark so it scans from a terminal with a dark background.
+func renderQR(code *qr.Code) string {
+	const quietZone = 2
+
+	var builder strings.Builder
+	for y := -quietZone; y < code.Size+quietZone; y += 2 {
+		for x := -quietZone; x < code.Size+quietZone; x++ {
+			top, bottom := !code.Black(x, y), !code.Black(x, y+1)
+			switch {
+			case top && bottom:
+				builder.WriteString("█")
+			case top:
+				builder.WriteString("▀")
+			case bottom:
+				builder.WriteString("▄")
+			default:
+				builder.WriteString(" ")
+			}
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
@@ -0,0 +1,101 @@
+// Package domofonClient calls the internal routes of domofon-api with requests signed by the shared SIGNING_KEY.
+package domofonClient
+
+import (
+	"fmt"
+	"net/http"
+
+	"domofon-api.gg/config"
+	"domofon-api.gg/signing"
+	"github.com/imroc/req/v3"
+)
+
+type Client struct {
+	client *req.Client
+}
+
+func New(config *config.Config) *Client {
+	signer := signing.NewSigner(config.SigningKey)
+
+	client := req.C().SetBaseURL(fmt.Sprintf("http://domofonapi:%d/api/internal", config.HttpPort))
+	client.GetTransport().WrapRoundTripFunc(func(rt http.RoundTripper) req.HttpRoundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			if err := signer.Sign(r); err != nil {
+				return nil, err
+			}
+			return rt.RoundTrip(r)
+		}
+	})
+
+	return &Client{client: client}
+}
+
+// APIError is an error response of domofon-api.
+type APIError struct {
+	Status         int    `json:"-"`                         // Status is the HTTP status of the response.
+	Message        string `json:"error"`                     // Message is a human-readable description.
+	Code           string `json:"code"`                      // Code is the machine-readable error code, such as "key_activation_failed".
+	UpstreamStatus int    `json:"upstream_status,omitempty"` // UpstreamStatus is the status returned by Rosdomofon, if any.
+}
+
+// Error tells the status and the code. The message is told only for responses without code, such as a 404 of the router.
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("domofon-api %d: %s", e.Status, e.Message)
+	}
+	if e.UpstreamStatus != 0 {
+		return fmt.Sprintf("domofon-api %d %s (rosdomofon status %d)", e.Status, e.Code, e.UpstreamStatus)
+	}
+	return fmt.Sprintf("domofon-api %d %s", e.Status, e.Code)
+}
+
+// Retryable reports whether domofon-api suggests retrying later: Rosdomofon was unreachable or failing.
+func (e *APIError) Retryable() bool {
+	return e.Status == http.StatusServiceUnavailable || e.Status == http.StatusGatewayTimeout
+}
+
+// Open asks domofon-api to open the named door for an SMS of the phone number.
+// identity is the name of the resident, empty if the allowlist is not enforced.
+// A refusal of domofon-api is returned as *APIError.
+func (c *Client) Open(door, identity, phone string) error {
+	var apiErr APIError
+	resp, err := c.client.R().
+		SetBody(map[string]string{
+			"door":     door,
+			"channel":  "sms",
+			"identity": identity,
+			"phone":    phone,
+		}).
+		SetErrorResult(&apiErr).
+		Post("/open")
+	if err != nil {
+		return err
+	}
+	if !resp.IsSuccessState() {
+		apiErr.Status = resp.StatusCode
+		if apiErr.Message == "" {
+			apiErr.Message = resp.Status
+		}
+		return &apiErr
+	}
+	return nil
+}
+
+// ReportDenied records in the audit log of domofon-api an SMS attempt refused by the checker.
+func (c *Client) ReportDenied(door, identity, phone, reason string) error {
+	resp, err := c.client.R().
+		SetBody(map[string]string{
+			"door":     door,
+			"identity": identity,
+			"phone":    phone,
+			"reason":   reason,
+		}).
+		Post("/audit")
+	if err != nil {
+		return err
+	}
+	if !resp.IsSuccessState() {
+		return fmt.Errorf("audit report failed with status: %s", resp.Status)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"go.uber.org/zap"
 	"net/http"
 	"net/http/cookiejar"
+	"time"
 )
 
 // Constants for content type and URLs
@@ -32,6 +33,9 @@ const (
 	// UrlSMSList is the endpoint to get a list of SMS messages.
 	UrlSMSList = "http://%s/api/sms/sms-list"
 
+	// UrlSMSCount is the endpoint to get the number of SMS messages in each box.
+	UrlSMSCount = "http://%s/api/sms/sms-count"
+
 	// UrlSendSMS is the endpoint to send an SMS message.
 	UrlSendSMS = "http://%s/api/sms/send-sms"
 
@@ -91,6 +95,7 @@ type Device struct {
 	deviceIP     string             // IP address of the device.
 	user         string             // Username for authentication.
 	password     string             // Password for authentication.
+	location     *time.Location     // Timezone of the modem clock, time.Local unless set.
 	deviceStatus *DeviceStatus
 }
 
@@ -104,6 +109,11 @@ func (d *Device) User() string {
 	return d.user
 }
 
+// SetLocation sets the timezone of the modem clock, used for the dates sent to the modem.
+func (d *Device) SetLocation(location *time.Location) {
+	d.location = location
+}
+
 // NewDevice creates a new instance of Device with the specified logger, device IP, username, and password.
 // It initializes the Device struct and sets up an HTTP client with a cookie jar to manage session cookies.
 //
@@ -121,6 +131,7 @@ func NewDevice(l *zap.SugaredLogger, deviceIP, user, password string) (*Device,
 		l:        l,
 		deviceIP: deviceIP,
 		user:     user,
+		location: time.Local,
 	}
 
 	// Hash and encode the password
@@ -5,10 +5,11 @@ Package huaweimodem provides a Go library for interacting with Huawei LTE WiFi r
 
 - Login and Logout: Authenticate and manage sessions with the modem.
 - Device Status: Retrieve comprehensive status information, including signal strength, battery level, and network status.
-- SMS Management: Send, read, and delete SMS messages.
+- SMS Management: Send, read, and delete SMS messages. Long messages are split into GSM-7 or UCS-2 parts.
 - Device Information: Get detailed information about the device.
 - Network and Signal Information: Obtain current network type, signal strength, and more.
 - Control Operations: Reboot the device and manage various settings.
+- Typed Errors: API error codes are returned as *APIError values that match sentinel errors such as ErrSMSFull with errors.Is.
 
 # Installation
 
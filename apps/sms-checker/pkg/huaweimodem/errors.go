@@ -0,0 +1,87 @@
+package huaweimodem
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Sentinel errors for the known API error codes.
+// An *APIError returned by a Device method matches the sentinel of its code with errors.Is.
+var (
+	ErrUnknown           = errors.New("unknown error")
+	ErrNotSupported      = errors.New("not supported")
+	ErrNoRights          = errors.New("no rights, login required")
+	ErrSystemBusy        = errors.New("system busy")
+	ErrFormat            = errors.New("format error")
+	ErrParameter         = errors.New("parameter error")
+	ErrWrongUsername     = errors.New("wrong username")
+	ErrWrongPassword     = errors.New("wrong password")
+	ErrAlreadyLoggedIn   = errors.New("already logged in")
+	ErrLockedOut         = errors.New("too many login attempts, locked out")
+	ErrSMSFull           = errors.New("SMS storage is full")
+	ErrWrongToken        = errors.New("wrong token")
+	ErrWrongSession      = errors.New("wrong session")
+	ErrWrongSessionToken = errors.New("wrong session token")
+)
+
+// errorCodes maps the numeric API error codes to their sentinel errors.
+var errorCodes = map[int]error{
+	100001: ErrUnknown,
+	100002: ErrNotSupported,
+	100003: ErrNoRights,
+	100004: ErrSystemBusy,
+	100005: ErrFormat,
+	100006: ErrParameter,
+	108001: ErrWrongUsername,
+	108002: ErrWrongPassword,
+	108003: ErrAlreadyLoggedIn,
+	108006: ErrLockedOut,
+	108007: ErrLockedOut,
+	113018: ErrSMSFull,
+	125001: ErrWrongToken,
+	125002: ErrWrongSession,
+	125003: ErrWrongSessionToken,
+}
+
+// APIError is returned when the modem answers a request with an error code.
+type APIError struct {
+	Code    int    // Code is the numeric error code returned by the API.
+	Message string // Message is the optional error message returned by the API.
+}
+
+func (e *APIError) Error() string {
+	text := fmt.Sprintf("error code %d", e.Code)
+	if known, ok := errorCodes[e.Code]; ok {
+		text = fmt.Sprintf("%s (%s)", text, known)
+	}
+	if e.Message != "" {
+		text = fmt.Sprintf("%s, message: %s", text, e.Message)
+	}
+	return text
+}
+
+// Unwrap returns the sentinel error of the code, so errors.Is(err, ErrSMSFull) and friends work.
+func (e *APIError) Unwrap() error {
+	return errorCodes[e.Code]
+}
+
+// newAPIError builds an *APIError from the textual code and message of a response.
+func newAPIError(code, message string) *APIError {
+	numericCode, err := strconv.Atoi(strings.TrimSpace(code))
+	if err != nil && message == "" {
+		message = fmt.Sprintf("unparsable error code %q", code)
+	}
+	return &APIError{Code: numericCode, Message: message}
+}
+
+// parseErrorResponse returns an *APIError if body is an <error> response, nil otherwise.
+func parseErrorResponse(body []byte) error {
+	var errorResponse ErrorResponse
+	if err := xml.Unmarshal(body, &errorResponse); err != nil {
+		return nil
+	}
+	return newAPIError(errorResponse.ErrorCode, errorResponse.Message)
+}
@@ -1,6 +1,7 @@
 package huaweimodem
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
@@ -17,6 +18,12 @@ type SesTokInfo struct {
 	TokInfo string   `xml:"TokInfo"`  // TokInfo is the token information.
 }
 
+// LoginResponse represents the response received after a login request.
+type LoginResponse struct {
+	XMLName xml.Name `xml:"response"`  // XMLName is the XML element name for the response.
+	Result  string   `xml:",chardata"` // Result is "OK" on a successful login.
+}
+
 // Login authenticates with the device by obtaining session and token information,
 // hashing the combined token, and sending a login request.
 func (d *Device) Login() (err error) {
@@ -27,46 +34,15 @@ func (d *Device) Login() (err error) {
 		return fmt.Errorf("failed to get SesTokInfo: %w", err)
 	}
 
-	// Combine user, password, and token, and hash the result
-	//combinedToken := fmt.Sprintf("%s%s%s", d.user, d.password, d.token)
-	//hashedCombinedToken := d.hashAndEncodePassword(combinedToken)
-	//
-	//// Create login payload
-	//loginPayload := fmt.Sprintf(`<request><Username>%s</Username><Password>%s</Password><password_type>4</password_type></request>`, d.user, hashedCombinedToken)
-	//req, err := http.NewRequest("POST", fmt.Sprintf(UrlLogin, d.deviceIP), bytes.NewBuffer([]byte(loginPayload)))
-	//if err != nil {
-	//	return fmt.Errorf("failed to create login request: %w", err)
-	//}
-	//
-	//// Set headers for the request
-	//req.Header.Set("Content-Type", httpContentType)
-	//req.Header.Set("__RequestVerificationToken", d.token)
-	//req.Header.Set("Cookie", d.sessionID)
-	//
-	//// Send the request
-	//resp, err := d.client.Do(req)
-	//if err != nil {
-	//	return fmt.Errorf("failed to send login request: %w", err)
-	//}
-	//defer resp.Body.Close()
-	//
-	//// Check for a successful response
-	//if resp.StatusCode != http.StatusOK {
-	//	return fmt.Errorf("login failed with status code %d", resp.StatusCode)
-	//}
-	//body, err := io.ReadAll(resp.Body)
-	//if err != nil {
-	//	return err
-	//}
-	//
-	//// Check for error response
-	//var errorResponse ErrorResponse
-	//if err := xml.Unmarshal(body, &errorResponse); err == nil {
-	//	return fmt.Errorf("error code %s", errorResponse.ErrorCode)
-	//}
+	// Modems without the web admin password accept API calls with just a session
+	if d.user != "" {
+		if err = d.sendLogin(); err != nil {
+			return err
+		}
+	}
 
 	d.l.Debug("login successfully")
-	d.deviceStatus, err = d.DeviceStatus()
+	d.deviceStatus, err = d.readDeviceStatus()
 	if err != nil {
 		return fmt.Errorf("failed to get device status: %w", err)
 	}
@@ -74,6 +50,68 @@ func (d *Device) Login() (err error) {
 	return nil
 }
 
+// sendLogin posts the credentials to the login endpoint using the password_type 4 scheme:
+// base64(sha256(user + base64(sha256(password)) + token)).
+func (d *Device) sendLogin() error {
+	// Combine user, password, and token, and hash the result (password_type 4)
+	combinedToken := fmt.Sprintf("%s%s%s", d.user, d.password, d.token)
+	hashedCombinedToken := d.hashAndEncodePassword(combinedToken)
+
+	// Create login payload
+	loginPayload := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?><request><Username>%s</Username><Password>%s</Password><password_type>4</password_type></request>`, d.user, hashedCombinedToken)
+	req, err := http.NewRequest("POST", fmt.Sprintf(UrlLogin, d.deviceIP), bytes.NewBufferString(loginPayload))
+	if err != nil {
+		return fmt.Errorf("failed to create login request: %w", err)
+	}
+
+	// Set headers for the request
+	req.Header.Set("Content-Type", httpContentType)
+	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+	req.Header.Set("__RequestVerificationToken", d.token)
+	req.Header.Set("Cookie", d.sessionID)
+
+	// Send the request
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send login request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check for a successful response
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("login failed with status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read login response: %w", err)
+	}
+
+	// Check for error response
+	if apiErr := parseErrorResponse(body); apiErr != nil {
+		return fmt.Errorf("login failed: %w", apiErr)
+	}
+
+	var loginResponse LoginResponse
+	if err := xml.Unmarshal(body, &loginResponse); err != nil {
+		return fmt.Errorf("failed to unmarshal login response: %w", err)
+	}
+	if loginResponse.Result != "OK" {
+		return fmt.Errorf("login failed, result: %s", loginResponse.Result)
+	}
+
+	// The modem rotates the session after a successful login
+	if token := resp.Header.Get("__RequestVerificationTokenone"); token != "" {
+		d.token = token
+	}
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "SessionID" {
+			d.sessionID = fmt.Sprintf("%s=%s", cookie.Name, cookie.Value)
+		}
+	}
+
+	return nil
+}
+
 // getSesTokInfo fetches the session and token information required for authentication.
 func (d *Device) getSesTokInfo() error {
 	client := d.client
@@ -109,18 +147,18 @@ func (d *Device) getSesTokInfo() error {
 	d.sessionID = sesTokInfo.SesInfo
 	d.token = sesTokInfo.TokInfo
 
-	d.l.Debug("sessionID: ", d.sessionID)
-	d.l.Debug("token: ", d.token)
+	d.l.Debug("session and token refreshed")
 
 	return nil
 }
 
-// hashAndEncodePassword hashes the provided password using SHA-256 and then encodes the hash in base64 format.
+// hashAndEncodePassword hashes the provided password using SHA-256 and then encodes the hex digest in base64 format,
+// the same way the modem's web UI does for password_type 4.
 func (d *Device) hashAndEncodePassword(password string) string {
 	hasher := sha256.New()
 	hasher.Write([]byte(password))
 	hashedPassword := hasher.Sum(nil)
 	hashedPasswordAsString := hex.EncodeToString(hashedPassword)
-	encodedPassword := base64.URLEncoding.EncodeToString([]byte(hashedPasswordAsString))
+	encodedPassword := base64.StdEncoding.EncodeToString([]byte(hashedPasswordAsString))
 	return encodedPassword
 }
@@ -0,0 +1,56 @@
+package huaweimodem
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SessionRecoveryError is returned when a request failed because the modem invalidated the session
+// or token, and logging in again or retrying the request did not help.
+type SessionRecoveryError struct {
+	Code int   // Code is the error code that triggered the recovery.
+	Err  error // Err is the error that made the recovery fail.
+}
+
+func (e *SessionRecoveryError) Error() string {
+	return fmt.Sprintf("failed to recover modem session after error code %d: %v", e.Code, e.Err)
+}
+
+func (e *SessionRecoveryError) Unwrap() error {
+	return e.Err
+}
+
+// isSessionError reports whether err was caused by an invalidated session or token,
+// or by a request that requires a logged-in session.
+func isSessionError(err error) (int, bool) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return 0, false
+	}
+	if errors.Is(apiErr, ErrNoRights) || errors.Is(apiErr, ErrWrongSession) || errors.Is(apiErr, ErrWrongSessionToken) {
+		return apiErr.Code, true
+	}
+	return 0, false
+}
+
+// withSessionRecovery runs call and, if it failed because the modem invalidated the session or token,
+// re-runs the login flow and retries call once.
+// A *SessionRecoveryError is returned if the login or the retried call fails.
+func (d *Device) withSessionRecovery(call func() error) error {
+	err := call()
+	code, ok := isSessionError(err)
+	if !ok {
+		return err
+	}
+
+	d.l.Warnf("modem rejected the session (error code %d), logging in again", code)
+	if err := d.Login(); err != nil {
+		return &SessionRecoveryError{Code: code, Err: err}
+	}
+
+	if err := call(); err != nil {
+		return &SessionRecoveryError{Code: code, Err: err}
+	}
+
+	return nil
+}
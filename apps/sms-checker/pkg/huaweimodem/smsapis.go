@@ -6,19 +6,30 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"iter"
 	"net/http"
+	"slices"
 	"time"
 )
 
+// smsDateLayout is the date format of the modem: "YYYY-MM-DD hh:mm:ss".
+const smsDateLayout = "2006-01-02 15:04:05"
+
+// ParseSMSDate parses a message date reported by the modem. The modem sends local dates without an offset,
+// location is the timezone the modem clock is set to.
+func ParseSMSDate(date string, location *time.Location) (time.Time, error) {
+	return time.ParseInLocation(smsDateLayout, date, location)
+}
+
 // SMS represents the structure of an SMS request to be sent.
 type SMS struct {
 	XMLName  xml.Name `xml:"request"`  // XMLName is the XML element name for the request.
 	Index    int      `xml:"Index"`    // Index is the message index, typically set to -1 for new messages.
 	Phones   Phones   `xml:"Phones"`   // Phones contains a list of phone numbers to send the SMS to.
 	Content  string   `xml:"Content"`  // Content is the text content of the SMS.
-	Length   int      `xml:"Length"`   // Length is the length of the SMS content.
+	Length   int      `xml:"Length"`   // Length is the length of the SMS content in characters of its encoding, not in bytes.
 	Reserved int      `xml:"Reserved"` // Reserved is a reserved field, often set to 1.
-	Date     string   `xml:"Date"`     // Date is the date the SMS is sent.
+	Date     string   `xml:"Date"`     // Date is the date the SMS is sent, in smsDateLayout.
 }
 
 // Phones represents a list of phone numbers for the SMS.
@@ -36,20 +47,143 @@ type SMSResponse struct {
 // SMSList represents the list of SMS messages retrieved from the device.
 type SMSList struct {
 	XMLName  xml.Name     `xml:"response"`         // XMLName is the XML element name for the response.
+	Count    int          `xml:"Count"`            // Count is the number of messages in this page.
 	Messages []SMSMessage `xml:"Messages>Message"` // Messages is a list of SMS messages.
 }
 
+// SMSBoxType selects the box listed by the SMS list endpoint.
+type SMSBoxType int
+
+// Box types accepted by the SMS list endpoint.
+const (
+	BoxInbox  SMSBoxType = 1 // BoxInbox lists received messages.
+	BoxOutbox SMSBoxType = 2 // BoxOutbox lists sent messages.
+	BoxDraft  SMSBoxType = 3 // BoxDraft lists saved drafts.
+)
+
+const (
+	// defaultSMSPageSize is the page size used when SMSListOptions.PageSize is not set.
+	defaultSMSPageSize = 20
+
+	// maxSMSPageSize is the largest page the modem returns in a single SMS list request.
+	maxSMSPageSize = 50
+
+	// defaultSMSCapacity is the message capacity assumed when the modem does not report LocalMax.
+	defaultSMSCapacity = 500
+)
+
+// SMSListOptions configures the SMS list requests made by ListSMS and AllSMS.
+type SMSListOptions struct {
+	BoxType         SMSBoxType // BoxType is the box to list, BoxInbox by default.
+	PageSize        int        // PageSize is the number of messages per page, 20 by default and at most 50.
+	SortType        int        // SortType is the sort key, 0 sorts by date.
+	Ascending       bool       // Ascending lists the oldest messages first.
+	UnreadPreferred bool       // UnreadPreferred lists unread messages before read ones.
+}
+
+// DefaultSMSListOptions returns the options used by ReadSMSInbox: newest inbox messages first, 20 per page.
+func DefaultSMSListOptions() SMSListOptions {
+	return SMSListOptions{
+		BoxType:  BoxInbox,
+		PageSize: defaultSMSPageSize,
+	}
+}
+
+// pageSize returns the effective page size of the options.
+func (o SMSListOptions) pageSize() int {
+	switch {
+	case o.PageSize <= 0:
+		return defaultSMSPageSize
+	case o.PageSize > maxSMSPageSize:
+		return maxSMSPageSize
+	default:
+		return o.PageSize
+	}
+}
+
+// boxType returns the effective box type of the options.
+func (o SMSListOptions) boxType() SMSBoxType {
+	if o.BoxType == 0 {
+		return BoxInbox
+	}
+	return o.BoxType
+}
+
+// smsListRequest represents the XML request body of the SMS list endpoint.
+type smsListRequest struct {
+	XMLName         xml.Name   `xml:"request"`
+	PageIndex       int        `xml:"PageIndex"`
+	ReadCount       int        `xml:"ReadCount"`
+	BoxType         SMSBoxType `xml:"BoxType"`
+	SortType        int        `xml:"SortType"`
+	Ascending       int        `xml:"Ascending"`
+	UnreadPreferred int        `xml:"UnreadPreferred"`
+}
+
+// SMSCount represents the number of SMS messages stored in each box of the device and the SIM card.
+type SMSCount struct {
+	XMLName      xml.Name `xml:"response"`     // XMLName is the XML element name for the response.
+	LocalUnread  int      `xml:"LocalUnread"`  // LocalUnread is the number of unread messages on the device.
+	LocalInbox   int      `xml:"LocalInbox"`   // LocalInbox is the number of received messages on the device.
+	LocalOutbox  int      `xml:"LocalOutbox"`  // LocalOutbox is the number of sent messages on the device.
+	LocalDraft   int      `xml:"LocalDraft"`   // LocalDraft is the number of drafts on the device.
+	LocalDeleted int      `xml:"LocalDeleted"` // LocalDeleted is the number of deleted messages on the device.
+	SimUnread    int      `xml:"SimUnread"`    // SimUnread is the number of unread messages on the SIM card.
+	SimInbox     int      `xml:"SimInbox"`     // SimInbox is the number of received messages on the SIM card.
+	SimOutbox    int      `xml:"SimOutbox"`    // SimOutbox is the number of sent messages on the SIM card.
+	SimDraft     int      `xml:"SimDraft"`     // SimDraft is the number of drafts on the SIM card.
+	LocalMax     int      `xml:"LocalMax"`     // LocalMax is the message capacity of the device.
+	SimMax       int      `xml:"SimMax"`       // SimMax is the message capacity of the SIM card.
+	SimUsed      int      `xml:"SimUsed"`      // SimUsed is the number of messages stored on the SIM card.
+	NewMsg       int      `xml:"NewMsg"`       // NewMsg is the number of new messages.
+}
+
+// Total returns the number of messages in the given box on the device.
+func (c *SMSCount) Total(box SMSBoxType) int {
+	switch box {
+	case BoxOutbox:
+		return c.LocalOutbox
+	case BoxDraft:
+		return c.LocalDraft
+	default:
+		return c.LocalInbox
+	}
+}
+
+// SMS read states reported in SMSMessage.Smstat.
+const (
+	SMSUnread = 0 // SMSUnread is the state of a message that was not read yet.
+	SMSRead   = 1 // SMSRead is the state of a message marked as read.
+)
+
+// SMS types reported in SMSMessage.SmsType, on modems that provide it.
+const (
+	SMSTypeSingle    = 1 // SMSTypeSingle is a standalone message.
+	SMSTypeMultipart = 2 // SMSTypeMultipart is one part of a concatenated message the modem did not reassemble.
+)
+
 // SMSMessage represents a single SMS message.
 type SMSMessage struct {
-	XMLName xml.Name `xml:"Message"` // XMLName is the XML element name for the message.
-	Index   int      `xml:"Index"`   // Index is the index of the message.
-	Phone   string   `xml:"Phone"`   // Phone is the phone number the message was sent from or to.
-	Content string   `xml:"Content"` // Content is the content of the message.
-	Date    string   `xml:"Date"`    // Date is the date the message was sent or received.
+	XMLName  xml.Name `xml:"Message"`  // XMLName is the XML element name for the message.
+	Smstat   int      `xml:"Smstat"`   // Smstat is the read state of the message, SMSUnread or SMSRead.
+	Index    int      `xml:"Index"`    // Index is the index of the message.
+	Phone    string   `xml:"Phone"`    // Phone is the phone number the message was sent from or to.
+	Content  string   `xml:"Content"`  // Content is the content of the message.
+	Date     string   `xml:"Date"`     // Date is the date the message was sent or received.
+	Sca      string   `xml:"Sca"`      // Sca is the SMS service center address, empty on some modems.
+	SaveType int      `xml:"SaveType"` // SaveType is where the message is stored, as reported by the modem.
+	Priority int      `xml:"Priority"` // Priority is the priority of the message.
+	SmsType  int      `xml:"SmsType"`  // SmsType is SMSTypeSingle or SMSTypeMultipart, 0 if the modem does not report it.
 }
 
-// DeleteSMSRequest represents the XML request to delete an SMS message.
+// DeleteSMSRequest represents the XML request to delete one or more SMS messages.
 type DeleteSMSRequest struct {
+	XMLName xml.Name `xml:"request"`
+	Index   []int    `xml:"Index"`
+}
+
+// SetSMSReadRequest represents the XML request to mark an SMS message as read.
+type SetSMSReadRequest struct {
 	XMLName xml.Name `xml:"request"`
 	Index   int      `xml:"Index"`
 }
@@ -60,29 +194,133 @@ type DeleteSMSResponse struct {
 	Result  string   `xml:"result"`
 }
 
-// ReadSMSInbox retrieves the SMS messages from the device's inbox.
-// It first checks if the user is logged in by verifying the sessionID.
-// If not logged in, it returns an error.
-// Then it refreshes the session and token information, and sends a request to the SMS list endpoint.
-// The response is parsed and unmarshaled into an SMSList struct, which is returned.
+// ReadSMSInbox retrieves all SMS messages from the device's inbox, newest first.
+// It walks every page of the inbox with AllSMS and DefaultSMSListOptions,
+// so messages beyond the first page are returned as well.
 //
 // Returns:
 //   - A pointer to the SMSList struct containing the SMS messages.
 //   - An error if any step in the process fails.
 func (d *Device) ReadSMSInbox() (*SMSList, error) {
+	var smsList SMSList
+	for message, err := range d.AllSMS(DefaultSMSListOptions()) {
+		if err != nil {
+			return nil, err
+		}
+		smsList.Messages = append(smsList.Messages, message)
+	}
+	smsList.Count = len(smsList.Messages)
+
+	return &smsList, nil
+}
+
+// AllSMS returns an iterator over every message of the box selected by opts.
+// The number of messages is read from the SMS count endpoint first, then the box is listed page by page
+// until a short page. If the count is not available, e.g. on firmwares without the endpoint,
+// the box is listed until a short page only.
+// Listing also stops when a page repeats the previous one, as on firmwares ignoring PageIndex,
+// and after the pages the capacity of the modem can fill.
+// Iteration stops after the first error, which is yielded with an empty message.
+// Messages that arrive or are deleted while the pages are walked may be skipped or yielded twice.
+func (d *Device) AllSMS(opts SMSListOptions) iter.Seq2[SMSMessage, error] {
+	return func(yield func(SMSMessage, error) bool) {
+		total, capacity := -1, defaultSMSCapacity
+		count, err := d.SMSCount()
+		if err != nil {
+			d.l.Warnf("failed to get SMS count, listing until the last page: %v", err)
+		} else {
+			total = count.Total(opts.boxType())
+			if count.LocalMax > 0 {
+				capacity = count.LocalMax
+			}
+		}
+
+		pageSize := opts.pageSize()
+		maxPages := capacity/pageSize + 1
+		var previous []int
+		for page, read := 1, 0; (total < 0 || read < total) && page <= maxPages; page++ {
+			smsList, err := d.ListSMS(page, opts)
+			if err != nil {
+				yield(SMSMessage{}, err)
+				return
+			}
+
+			indexes := make([]int, 0, len(smsList.Messages))
+			for _, message := range smsList.Messages {
+				indexes = append(indexes, message.Index)
+			}
+			if len(indexes) > 0 && slices.Equal(indexes, previous) {
+				d.l.Warnf("SMS page %d repeats the previous one, the modem ignores PageIndex", page)
+				return
+			}
+			previous = indexes
+
+			for _, message := range smsList.Messages {
+				if !yield(message, nil) {
+					return
+				}
+			}
+
+			read += len(smsList.Messages)
+			if len(smsList.Messages) < pageSize {
+				return
+			}
+		}
+	}
+}
+
+// ListSMS retrieves a single page of SMS messages, starting at page 1, from the box selected by opts.
+// It first checks if the user is logged in by verifying the sessionID.
+// If not logged in, it returns an error.
+// If the modem rejects the session or token, the login flow is re-run and the request retried once.
+//
+// Parameters:
+//   - page: The 1-based index of the page to read.
+//   - opts: The box, page size and ordering of the listing.
+//
+// Returns:
+//   - A pointer to the SMSList struct containing the SMS messages of the page.
+//   - An error if any step in the process fails.
+func (d *Device) ListSMS(page int, opts SMSListOptions) (*SMSList, error) {
 	if d.sessionID == "" {
 		return nil, fmt.Errorf("you must login first")
 	}
 
+	var smsList *SMSList
+	err := d.withSessionRecovery(func() (err error) {
+		smsList, err = d.listSMS(page, opts)
+		return err
+	})
+	return smsList, err
+}
+
+// listSMS sends a single SMS list request without session recovery.
+func (d *Device) listSMS(page int, opts SMSListOptions) (*SMSList, error) {
 	err := d.getSesTokInfo()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get SesTokInfo: %w", err)
 	}
 
-	reqBody := `<?xml version="1.0" encoding="UTF-8"?><request><PageIndex>1</PageIndex><ReadCount>20</ReadCount><BoxType>1</BoxType><SortType>0</SortType><Ascending>0</Ascending><UnreadPreferred>0</UnreadPreferred></request>`
+	listRequest := smsListRequest{
+		PageIndex: page,
+		ReadCount: opts.pageSize(),
+		BoxType:   opts.boxType(),
+		SortType:  opts.SortType,
+	}
+	if opts.Ascending {
+		listRequest.Ascending = 1
+	}
+	if opts.UnreadPreferred {
+		listRequest.UnreadPreferred = 1
+	}
+
+	xmlData, err := xml.Marshal(listRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SMS list request: %w", err)
+	}
 
 	client := d.client
-	req, err := http.NewRequest("POST", fmt.Sprintf(UrlSMSList, d.deviceIP), bytes.NewBufferString(reqBody))
+	req, err := http.NewRequest("POST", fmt.Sprintf(UrlSMSList, d.deviceIP), bytes.NewBuffer(append([]byte(xml.Header), xmlData...)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create SMS list request: %w", err)
 	}
@@ -103,10 +341,9 @@ func (d *Device) ReadSMSInbox() (*SMSList, error) {
 	}
 
 	var smsList SMSList
-	var errorResponse ErrorResponse
 	if err := xml.Unmarshal(body, &smsList); err != nil {
-		if err := xml.Unmarshal(body, &errorResponse); err == nil {
-			return nil, fmt.Errorf("error code %s", errorResponse.ErrorCode)
+		if apiErr := parseErrorResponse(body); apiErr != nil {
+			return nil, apiErr
 		}
 		return nil, fmt.Errorf("failed to unmarshal SMS list: %w", err)
 	}
@@ -114,23 +351,120 @@ func (d *Device) ReadSMSInbox() (*SMSList, error) {
 	return &smsList, nil
 }
 
+// SMSCount retrieves the number of SMS messages stored in each box.
+// It first checks if the user is logged in by verifying the sessionID.
+// If not logged in, it returns an error.
+// If the modem rejects the session or token, the login flow is re-run and the request retried once.
+//
+// Returns:
+//   - A pointer to the SMSCount struct containing the message counts.
+//   - An error if any step in the process fails.
+func (d *Device) SMSCount() (*SMSCount, error) {
+	if d.sessionID == "" {
+		return nil, fmt.Errorf("you must login first")
+	}
+
+	var count *SMSCount
+	err := d.withSessionRecovery(func() (err error) {
+		count, err = d.readSMSCount()
+		return err
+	})
+	return count, err
+}
+
+// readSMSCount sends a single SMS count request without session recovery.
+func (d *Device) readSMSCount() (*SMSCount, error) {
+	err := d.getSesTokInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SesTokInfo: %w", err)
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf(UrlSMSCount, d.deviceIP), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SMS count request: %w", err)
+	}
+	req.Header.Set("Cookie", d.sessionID)
+	req.Header.Set("__RequestVerificationToken", d.token)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send SMS count request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SMS count response: %w", err)
+	}
+
+	var count SMSCount
+	if err := xml.Unmarshal(body, &count); err != nil {
+		if apiErr := parseErrorResponse(body); apiErr != nil {
+			return nil, apiErr
+		}
+		return nil, fmt.Errorf("failed to unmarshal SMS count: %w", err)
+	}
+
+	return &count, nil
+}
+
+// SMSSendResult is the result of sending one part of a message.
+type SMSSendResult struct {
+	Part     int         // Part is the 1-based number of the part.
+	Content  string      // Content is the text of the part.
+	Length   int         // Length is the length of the part in characters of the encoding.
+	Encoding SMSEncoding // Encoding is the alphabet the part is sent in.
+	Err      error       // Err is the error of sending the part, nil if the modem accepted it.
+}
+
 // SendSMS sends an SMS message to the specified phone number.
 // It first checks if the user is logged in by verifying the sessionID.
 // If not logged in, it returns an error.
-// Then it refreshes the session and token information, constructs the SMS message,
-// and sends it to the SMS send endpoint.
+// The encoding is detected from the message: GSM-7 if every character is in the GSM 7-bit alphabet, UCS-2 otherwise.
+// A message longer than a single SMS of that encoding (160 or 70 characters) is split into parts,
+// which are sent one after another as separate SMS. Sending stops at the first failed part.
 //
 // Parameters:
 //   - phoneNumber: The phone number to send the SMS to.
 //   - message: The message content to be sent.
 //
+// If the modem rejects the session or token, the login flow is re-run and the request retried once.
+//
 // Returns:
-//   - An error if any step in the process fails.
-func (d *Device) SendSMS(phoneNumber, message string) error {
+//   - The result of every part that was attempted.
+//   - The error of the first failed part, if any.
+func (d *Device) SendSMS(phoneNumber, message string) ([]SMSSendResult, error) {
 	if d.sessionID == "" {
-		return fmt.Errorf("you must login first")
+		return nil, fmt.Errorf("you must login first")
+	}
+
+	encoding := DetectEncoding(message)
+	parts := SplitSMS(message, encoding)
+
+	results := make([]SMSSendResult, 0, len(parts))
+	for i, part := range parts {
+		result := SMSSendResult{
+			Part:     i + 1,
+			Content:  part,
+			Length:   SMSLength(part, encoding),
+			Encoding: encoding,
+		}
+		result.Err = d.withSessionRecovery(func() error {
+			return d.sendSMS(phoneNumber, part, result.Length)
+		})
+		results = append(results, result)
+
+		if result.Err != nil {
+			return results, fmt.Errorf("failed to send part %d/%d: %w", result.Part, len(parts), result.Err)
+		}
 	}
 
+	return results, nil
+}
+
+// sendSMS sends a single SMS send request without session recovery.
+// length is the length of the message in characters of its encoding.
+func (d *Device) sendSMS(phoneNumber, message string, length int) error {
 	err := d.getSesTokInfo()
 	if err != nil {
 		return fmt.Errorf("failed to get SesTokInfo: %w", err)
@@ -140,9 +474,9 @@ func (d *Device) SendSMS(phoneNumber, message string) error {
 		Index:    -1,
 		Phones:   Phones{Phone: []string{phoneNumber}},
 		Content:  message,
-		Length:   len(message),
+		Length:   length,
 		Reserved: 1,
-		Date:     time.Now().String(),
+		Date:     time.Now().In(d.location).Format(smsDateLayout),
 	}
 
 	xmlData, err := xml.Marshal(sms)
@@ -171,63 +505,67 @@ func (d *Device) SendSMS(phoneNumber, message string) error {
 	}
 
 	var smsResponse SMSResponse
-	var errorResponse ErrorResponse
 
 	if err := xml.Unmarshal(body, &smsResponse); err == nil {
 		if smsResponse.ErrorCode != "" {
-			return fmt.Errorf("error code %s", smsResponse.ErrorCode)
+			return newAPIError(smsResponse.ErrorCode, smsResponse.Message)
 		}
 		d.l.Debug("SMS sent successfully")
 		return nil
-	} else if err := xml.Unmarshal(body, &errorResponse); err == nil {
-		return fmt.Errorf("error code %s", errorResponse.ErrorCode)
+	} else if apiErr := parseErrorResponse(body); apiErr != nil {
+		return apiErr
 	} else {
 		return fmt.Errorf("unexpected response format")
 	}
 }
 
-// DeleteSMSWithIndex deletes an SMS message with the specified index from the inbox.
-// It first checks if the user is logged in by verifying the sessionID.
-// If not logged in, it returns an error.
-// Then it reads the SMS inbox to ensure the message with the given index exists.
-// If the message exists, it sends a request to the delete SMS endpoint to remove the message.
+// DeleteSMSWithIndex deletes an SMS message with the specified index.
+// It is DeleteSMS with a single index: the inbox is not read to check that the index exists,
+// listing every page of the inbox to delete one message would cost more than the deletion.
 //
 // Parameters:
 //   - index: The index of the SMS message to be deleted.
 //
+// If the modem rejects the session or token, the login flow is re-run and the request retried once.
+//
 // Returns:
 //   - An error if any step in the process fails.
 func (d *Device) DeleteSMSWithIndex(index int) error {
+	return d.DeleteSMS(index)
+}
+
+// DeleteSMS deletes the SMS messages with the specified indexes in a single request.
+// It does not read the inbox to check that the indexes exist.
+// It first checks if the user is logged in by verifying the sessionID.
+// If not logged in, it returns an error.
+// If the modem rejects the session or token, the login flow is re-run and the request retried once.
+//
+// Parameters:
+//   - indexes: The indexes of the SMS messages to be deleted.
+//
+// Returns:
+//   - An error if any step in the process fails.
+func (d *Device) DeleteSMS(indexes ...int) error {
 	if d.sessionID == "" {
 		return fmt.Errorf("you must login first")
 	}
-
-	if messages, err := d.ReadSMSInbox(); err == nil {
-		if len(messages.Messages) == 0 {
-			return fmt.Errorf("no messages to delete")
-		}
-
-		foundIndex := false
-		for _, message := range messages.Messages {
-			if message.Index == index {
-				foundIndex = true
-				break
-			}
-		}
-		if !foundIndex {
-			return fmt.Errorf("message with index %d not found", index)
-		}
-
-	} else {
-		return fmt.Errorf("failed to read SMS inbox: %w", err)
+	if len(indexes) == 0 {
+		return nil
 	}
 
+	return d.withSessionRecovery(func() error {
+		return d.deleteSMS(indexes)
+	})
+}
+
+// deleteSMS sends a single delete SMS request without session recovery.
+func (d *Device) deleteSMS(indexes []int) error {
 	err := d.getSesTokInfo()
 	if err != nil {
 		return fmt.Errorf("failed to get SesTokInfo: %w", err)
 	}
 
-	deleteRequest := DeleteSMSRequest{Index: index}
+	deleteRequest := DeleteSMSRequest{Index: indexes}
 	xmlData, err := xml.Marshal(deleteRequest)
 	if err != nil {
 		return fmt.Errorf("failed to marshal delete request: %w", err)
@@ -262,9 +600,8 @@ func (d *Device) DeleteSMSWithIndex(index int) error {
 
 	var deleteResponse DeleteSMSResponse
 	if err := xml.Unmarshal(body, &deleteResponse); err != nil {
-		var errorResponse ErrorResponse
-		if err = xml.Unmarshal(body, &errorResponse); err == nil {
-			return fmt.Errorf("error code %s, message: %s", errorResponse.ErrorCode, errorResponse.Message)
+		if apiErr := parseErrorResponse(body); apiErr != nil {
+			return apiErr
 		}
 
 		return fmt.Errorf("failed to unmarshal delete SMS response: %w", err)
@@ -274,7 +611,77 @@ func (d *Device) DeleteSMSWithIndex(index int) error {
 		return fmt.Errorf("failed to delete SMS, result: %s", deleteResponse.Result)
 	}
 
-	d.l.Debug("SMS deleted successfully")
+	d.l.Debugf("%d SMS deleted successfully", len(indexes))
+
+	return nil
+}
+
+// MarkSMSRead marks the SMS messages with the specified indexes as read.
+// It first checks if the user is logged in by verifying the sessionID.
+// If not logged in, it returns an error.
+// The modem accepts a single index per request, so one request is sent for each index;
+// marking stops at the first failure.
+// If the modem rejects the session or token, the login flow is re-run and the request retried once.
+//
+// Parameters:
+//   - indexes: The indexes of the SMS messages to be marked as read.
+//
+// Returns:
+//   - An error if any step in the process fails.
+func (d *Device) MarkSMSRead(indexes ...int) error {
+	if d.sessionID == "" {
+		return fmt.Errorf("you must login first")
+	}
+
+	for _, index := range indexes {
+		err := d.withSessionRecovery(func() error {
+			return d.setSMSRead(index)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to mark SMS %d as read: %w", index, err)
+		}
+	}
+
+	return nil
+}
+
+// setSMSRead sends a single set read request without session recovery.
+func (d *Device) setSMSRead(index int) error {
+	err := d.getSesTokInfo()
+	if err != nil {
+		return fmt.Errorf("failed to get SesTokInfo: %w", err)
+	}
+
+	xmlData, err := xml.Marshal(SetSMSReadRequest{Index: index})
+	if err != nil {
+		return fmt.Errorf("failed to marshal set read request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf(UrlSetSMSRead, d.deviceIP), bytes.NewBuffer(xmlData))
+	if err != nil {
+		return fmt.Errorf("failed to create set read request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", httpContentType)
+	req.Header.Set("Cookie", d.sessionID)
+	req.Header.Set("__RequestVerificationToken", d.token)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send set read request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read set read response: %w", err)
+	}
+
+	if apiErr := parseErrorResponse(body); apiErr != nil {
+		return apiErr
+	}
+
+	d.l.Debugf("SMS %d marked as read", index)
 
 	return nil
 }
@@ -0,0 +1,116 @@
+package huaweimodem
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// SMSEncoding is the alphabet an SMS is sent in.
+type SMSEncoding int
+
+const (
+	// EncodingGSM7 is the GSM 03.38 7-bit default alphabet, 160 characters per SMS.
+	EncodingGSM7 SMSEncoding = iota
+	// EncodingUCS2 is UCS-2 (UTF-16), 70 characters per SMS. It is used as soon as a character is not in GSM 7-bit,
+	// which is the case for any Cyrillic text.
+	EncodingUCS2
+)
+
+// String returns the name of the encoding.
+func (e SMSEncoding) String() string {
+	if e == EncodingUCS2 {
+		return "UCS-2"
+	}
+	return "GSM-7"
+}
+
+// Capacity returns how many characters of the encoding fit in a single SMS.
+func (e SMSEncoding) Capacity() int {
+	if e == EncodingUCS2 {
+		return 70
+	}
+	return 160
+}
+
+// PartCapacity returns how many characters of the encoding fit in one part of a concatenated SMS (153 or 67),
+// the rest of the part is taken by the user data header linking the parts.
+func (e SMSEncoding) PartCapacity() int {
+	if e == EncodingUCS2 {
+		return 67
+	}
+	return 153
+}
+
+// gsm7Basic is the GSM 03.38 basic character set, each character takes one septet.
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension is the GSM 03.38 extension table, each character takes two septets (escape and character).
+const gsm7Extension = "\f^{}\\[~]|€"
+
+// DetectEncoding returns GSM-7 if every character of the text is in the GSM 7-bit alphabet, UCS-2 otherwise.
+func DetectEncoding(text string) SMSEncoding {
+	for _, r := range text {
+		if !strings.ContainsRune(gsm7Basic, r) && !strings.ContainsRune(gsm7Extension, r) {
+			return EncodingUCS2
+		}
+	}
+	return EncodingGSM7
+}
+
+// runeLength returns how many characters of the encoding the rune takes:
+// septets for GSM-7, UTF-16 code units for UCS-2.
+func runeLength(r rune, encoding SMSEncoding) int {
+	if encoding == EncodingUCS2 {
+		return utf16.RuneLen(r)
+	}
+	if strings.ContainsRune(gsm7Extension, r) {
+		return 2
+	}
+	return 1
+}
+
+// SMSLength returns the length of the text as counted by the modem and the network:
+// septets for GSM-7, UTF-16 code units for UCS-2. It differs from len(text), which counts bytes.
+func SMSLength(text string, encoding SMSEncoding) int {
+	length := 0
+	for _, r := range text {
+		length += runeLength(r, encoding)
+	}
+	return length
+}
+
+// SplitSMS splits the text into parts that each fit a single SMS of the encoding.
+// Parts are cut at the last space when possible, and never inside an extension character or a surrogate pair.
+func SplitSMS(text string, encoding SMSEncoding) []string {
+	capacity := encoding.Capacity()
+	if SMSLength(text, encoding) <= capacity {
+		return []string{text}
+	}
+
+	var parts []string
+	runes := []rune(text)
+	for len(runes) > 0 {
+		length, end, lastSpace := 0, 0, -1
+		for end < len(runes) {
+			size := runeLength(runes[end], encoding)
+			if length+size > capacity {
+				break
+			}
+			if runes[end] == ' ' {
+				lastSpace = end
+			}
+			length += size
+			end++
+		}
+
+		cut, next := end, end
+		if end < len(runes) && lastSpace > 0 {
+			cut, next = lastSpace, lastSpace+1
+		}
+		parts = append(parts, string(runes[:cut]))
+		runes = runes[next:]
+	}
+
+	return parts
+}
@@ -198,6 +198,8 @@ func (d *DeviceStatus) GetCellRoam() int {
 // Then it refreshes the session and token information, and sends a request to the device status endpoint.
 // The response is parsed and unmarshalled into a DeviceStatus struct, which is returned.
 //
+// If the modem rejects the session or token, the login flow is re-run and the request retried once.
+//
 // Returns:
 //   - A pointer to the DeviceStatus struct containing the device status.
 //   - An error if any step in the process fails.
@@ -206,6 +208,16 @@ func (d *Device) DeviceStatus() (*DeviceStatus, error) {
 		return nil, fmt.Errorf("you must login first")
 	}
 
+	var status *DeviceStatus
+	err := d.withSessionRecovery(func() (err error) {
+		status, err = d.readDeviceStatus()
+		return err
+	})
+	return status, err
+}
+
+// readDeviceStatus sends a single device status request without session recovery.
+func (d *Device) readDeviceStatus() (*DeviceStatus, error) {
 	err := d.getSesTokInfo()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get SesTokInfo: %w", err)
@@ -232,6 +244,9 @@ func (d *Device) DeviceStatus() (*DeviceStatus, error) {
 
 	var status DeviceStatus
 	if err := xml.Unmarshal(body, &status); err != nil {
+		if apiErr := parseErrorResponse(body); apiErr != nil {
+			return nil, apiErr
+		}
 		return nil, fmt.Errorf("failed to unmarshal status response: %w", err)
 	}
 
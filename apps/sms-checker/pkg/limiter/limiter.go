@@ -0,0 +1,104 @@
+// Package limiter limits the attempts of each sender number: too many failed codes lock the number out,
+// and successful opens are capped per hour. The state is kept in the message store and survives restarts.
+package limiter
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"domofon-api/pkg/messageStore"
+
+	"domofon-api.gg/config"
+)
+
+var (
+	// ErrLockedOut is returned for a number locked out after too many failed attempts.
+	ErrLockedOut = errors.New("locked out after too many failed attempts")
+	// ErrTooManyOpens is returned for a number that reached the hourly cap of opens.
+	ErrTooManyOpens = errors.New("too many opens in the last hour")
+)
+
+// Limiter tracks failed attempts and opens per sender number.
+// Zero limits disable the corresponding rule.
+type Limiter struct {
+	store        *messageStore.Store
+	maxFailures  int
+	window       time.Duration
+	lockout      time.Duration
+	opensPerHour int
+}
+
+func New(store *messageStore.Store, config *config.Config) *Limiter {
+	return &Limiter{
+		store:        store,
+		maxFailures:  config.FailedAttemptsLimit,
+		window:       time.Duration(config.FailedAttemptsWindow) * time.Minute,
+		lockout:      time.Duration(config.LockoutTime) * time.Minute,
+		opensPerHour: config.OpensPerHour,
+	}
+}
+
+// Check returns ErrLockedOut or ErrTooManyOpens, wrapped with the time the number may try again,
+// if the number may not open the door now.
+func (l *Limiter) Check(phone string, now time.Time) error {
+	limit, err := l.store.GetLimit(phone)
+	if err != nil {
+		return err
+	}
+
+	if now.Before(limit.LockedUntil) {
+		return fmt.Errorf("%w until %s", ErrLockedOut, limit.LockedUntil.Format(time.DateTime))
+	}
+
+	if l.opensPerHour > 0 {
+		opens := since(limit.Opens, now.Add(-time.Hour))
+		if len(opens) >= l.opensPerHour {
+			return fmt.Errorf("%w, next at %s", ErrTooManyOpens, opens[0].Add(time.Hour).Format(time.DateTime))
+		}
+	}
+
+	return nil
+}
+
+// Failure records a failed attempt and locks the number out once it reaches the limit within the window.
+func (l *Limiter) Failure(phone string, now time.Time) error {
+	if l.maxFailures <= 0 {
+		return nil
+	}
+
+	return l.store.UpdateLimit(phone, func(limit *messageStore.PhoneLimit) {
+		limit.Failures = append(since(limit.Failures, now.Add(-l.window)), now)
+		if len(limit.Failures) >= l.maxFailures {
+			limit.LockedUntil = now.Add(l.lockout)
+			limit.Failures = nil
+			log.Printf("Number %s locked out until %s\n", phone, limit.LockedUntil.Format(time.DateTime))
+		}
+	})
+}
+
+// Success records an open and forgets the failed attempts of the number.
+func (l *Limiter) Success(phone string, now time.Time) error {
+	return l.store.UpdateLimit(phone, func(limit *messageStore.PhoneLimit) {
+		limit.Failures = nil
+		if now.After(limit.LockedUntil) {
+			limit.LockedUntil = time.Time{}
+		}
+		if l.opensPerHour > 0 {
+			limit.Opens = append(since(limit.Opens, now.Add(-time.Hour)), now)
+		} else {
+			limit.Opens = nil
+		}
+	})
+}
+
+// since returns the times after start, the times being in chronological order.
+func since(times []time.Time, start time.Time) []time.Time {
+	for i, t := range times {
+		if t.After(start) {
+			return times[i:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package messageStore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// pausedUntilKey is the meta key holding the end of the pause of remote opening.
+var pausedUntilKey = []byte("paused_until")
+
+// AuditEntry is the record of an admin command.
+type AuditEntry struct {
+	Time    time.Time `json:"time"`            // Time is when the command was executed.
+	Phone   string    `json:"phone"`           // Phone is the admin number the command was sent from.
+	Command string    `json:"command"`         // Command is the canonical name of the command.
+	Args    []string  `json:"args,omitempty"`  // Args are the arguments of the command.
+	Result  string    `json:"result"`          // Result is the reply sent to the admin.
+	Error   string    `json:"error,omitempty"` // Error is the error of the command, empty if it succeeded.
+}
+
+// AddAudit appends an entry to the admin audit log.
+func (s *Store) AddAudit(entry AuditEntry) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(auditBucket)
+		sequence, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, sequence)
+		return putJSON(tx, auditBucket, key, entry)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to audit %s command: %w", entry.Command, err)
+	}
+	return nil
+}
+
+// ListAudit returns the last entries of the admin audit log, newest first. A zero limit returns all entries.
+func (s *Store) ListAudit(limit int) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(auditBucket).Cursor()
+		for key, _ := cursor.Last(); key != nil && (limit <= 0 || len(entries) < limit); key, _ = cursor.Prev() {
+			var entry AuditEntry
+			if _, err := getJSON(tx, auditBucket, key, &entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// PausedUntil returns the end of the pause of remote opening, zero if it is not paused.
+func (s *Store) PausedUntil() (time.Time, error) {
+	var until time.Time
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(metaBucket).Get(pausedUntilKey)
+		if data == nil {
+			return nil
+		}
+		return until.UnmarshalText(data)
+	})
+	return until, err
+}
+
+// SetPausedUntil pauses remote opening until the time; a zero time resumes it.
+func (s *Store) SetPausedUntil(until time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if until.IsZero() {
+			return tx.Bucket(metaBucket).Delete(pausedUntilKey)
+		}
+		data, err := until.MarshalText()
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(metaBucket).Put(pausedUntilKey, data)
+	})
+}
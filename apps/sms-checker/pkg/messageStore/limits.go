@@ -0,0 +1,47 @@
+package messageStore
+
+import (
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// PhoneLimit is the attempt history of a sender number, kept so a restart does not reset lockouts.
+type PhoneLimit struct {
+	Failures    []time.Time `json:"failures,omitempty"`     // Failures are the times of the recent failed attempts.
+	Opens       []time.Time `json:"opens,omitempty"`        // Opens are the times of the recent successful opens.
+	LockedUntil time.Time   `json:"locked_until,omitempty"` // LockedUntil is the end of the current lockout, zero if none.
+}
+
+// GetLimit returns the attempt history of the number, empty if it has none.
+func (s *Store) GetLimit(phone string) (PhoneLimit, error) {
+	var limit PhoneLimit
+	err := s.db.View(func(tx *bolt.Tx) error {
+		_, err := getJSON(tx, limitsBucket, []byte(phone), &limit)
+		return err
+	})
+	return limit, err
+}
+
+// UpdateLimit changes the attempt history of the number in a single transaction.
+// update gets the current history, empty if the number has none; an empty result deletes the record.
+func (s *Store) UpdateLimit(phone string, update func(limit *PhoneLimit)) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var limit PhoneLimit
+		if _, err := getJSON(tx, limitsBucket, []byte(phone), &limit); err != nil {
+			return err
+		}
+
+		update(&limit)
+
+		if len(limit.Failures) == 0 && len(limit.Opens) == 0 && limit.LockedUntil.IsZero() {
+			return tx.Bucket(limitsBucket).Delete([]byte(phone))
+		}
+		return putJSON(tx, limitsBucket, []byte(phone), limit)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update limits of %s: %w", phone, err)
+	}
+	return nil
+}
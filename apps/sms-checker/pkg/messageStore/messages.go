@@ -0,0 +1,138 @@
+package messageStore
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Status is the processing status of a stored message.
+type Status string
+
+const (
+	// StatusPending means the message was recorded but its handler has not finished yet.
+	StatusPending Status = "pending"
+	// StatusProcessed means the handler finished with the message.
+	StatusProcessed Status = "processed"
+	// StatusSkipped means the message was not dispatched, see Message.Decision for the reason.
+	StatusSkipped Status = "skipped"
+)
+
+// Message is the record of an SMS seen on the modem.
+// Records are keyed by Fingerprint, not by Index: the modem reuses indexes after messages are deleted
+// or the stick is reset, so an index alone does not identify a message.
+type Message struct {
+	Fingerprint string    `json:"fingerprint"`            // Fingerprint identifies the message, see Fingerprint.
+	Index       int       `json:"index"`                  // Index is the index of the message on the modem.
+	Phone       string    `json:"phone,omitempty"`        // Phone is the sender of the message.
+	ModemDate   string    `json:"modem_date,omitempty"`   // ModemDate is the date exactly as reported by the modem.
+	Date        time.Time `json:"date,omitempty"`         // Date is the parsed ModemDate.
+	ContentHash string    `json:"content_hash,omitempty"` // ContentHash is the hex SHA-256 of the message content.
+	Decision    string    `json:"decision,omitempty"`     // Decision is what the handler made of the message.
+	Resident    string    `json:"resident,omitempty"`     // Resident is the name of the resident identified as the sender.
+	Status      Status    `json:"status"`                 // Status is the processing status of the message.
+	SeenAt      time.Time `json:"seen_at"`                // SeenAt is when the poller first saw the message.
+	ProcessedAt time.Time `json:"processed_at,omitempty"` // ProcessedAt is when the handler finished with the message.
+	DeletedAt   time.Time `json:"deleted_at,omitempty"`   // DeletedAt is when the message was deleted from the modem.
+}
+
+// HashContent returns the hex SHA-256 of an SMS content, stored instead of the content itself.
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Fingerprint returns the identity of a message: the hex SHA-256 of its sender, modem date,
+// content hash and index. A reused index with a different sender, date or content yields a new fingerprint.
+func Fingerprint(phone, modemDate, contentHash string, index int) string {
+	hasher := sha256.New()
+	for _, field := range []string{phone, modemDate, contentHash, strconv.Itoa(index)} {
+		hasher.Write([]byte(field))
+		hasher.Write([]byte{0})
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// indexKey returns the bucket key of a message index.
+func indexKey(index int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(index))
+	return key
+}
+
+// legacyIndex is an index imported from the legacy LAST_SMS_FILE, for which only the index is known.
+type legacyIndex struct {
+	Index      int       `json:"index"`       // Index is the imported message index.
+	ImportedAt time.Time `json:"imported_at"` // ImportedAt is when the index was imported.
+}
+
+// HasMessage reports whether the message was already recorded.
+// The message is looked up by fingerprint first. Messages handled before the fingerprint store existed
+// are only known by index: such an index matches if the message is dated before the index was imported,
+// so a newer message reusing the index is still seen as new.
+func (s *Store) HasMessage(message Message) (bool, error) {
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(messagesBucket).Get([]byte(message.Fingerprint)) != nil {
+			found = true
+			return nil
+		}
+
+		var legacy legacyIndex
+		ok, err := getJSON(tx, legacyIndexesBucket, indexKey(message.Index), &legacy)
+		if err != nil || !ok {
+			return err
+		}
+		found = !message.Date.IsZero() && message.Date.Before(legacy.ImportedAt)
+		return nil
+	})
+	return found, err
+}
+
+// SaveMessage creates or replaces the record of a message.
+// A legacy index matching the message is dropped, as the fingerprint now covers it.
+func (s *Store) SaveMessage(message Message) error {
+	if message.Fingerprint == "" {
+		return fmt.Errorf("failed to save message %d: empty fingerprint", message.Index)
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if err := putJSON(tx, messagesBucket, []byte(message.Fingerprint), message); err != nil {
+			return err
+		}
+		return tx.Bucket(legacyIndexesBucket).Delete(indexKey(message.Index))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save message %d: %w", message.Index, err)
+	}
+	return nil
+}
+
+// MarkDeleted records that the messages were deleted from the modem.
+// The records are kept as history; their indexes may be reused by the modem without being swallowed.
+func (s *Store) MarkDeleted(fingerprints ...string) error {
+	now := time.Now()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, fingerprint := range fingerprints {
+			var message Message
+			found, err := getJSON(tx, messagesBucket, []byte(fingerprint), &message)
+			if err != nil {
+				return err
+			}
+			if !found {
+				continue
+			}
+
+			message.DeletedAt = now
+			if err := putJSON(tx, messagesBucket, []byte(fingerprint), message); err != nil {
+				return fmt.Errorf("failed to mark message %d deleted: %w", message.Index, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,145 @@
+package messageStore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// legacyImportedKey is the meta key set once the legacy LAST_SMS_FILE was imported.
+	legacyImportedKey = []byte("legacy_imported_at")
+
+	// schemaVersionKey is the meta key holding the version of the bucket layout.
+	schemaVersionKey = []byte("schema_version")
+)
+
+// schemaVersion is the current version of the bucket layout:
+//   - 1: messages keyed by modem index.
+//   - 2: messages keyed by fingerprint, legacy indexes in their own bucket.
+const schemaVersion = 2
+
+// ImportLegacyFile imports the message indexes of the JSON file formerly used by the poller (LAST_SMS_FILE),
+// so messages handled before the upgrade are not dispatched again.
+// The import runs once: the file is renamed to path + ".imported" afterwards.
+// A missing file is not an error. It returns the number of imported indexes.
+func (s *Store) ImportLegacyFile(path string) (int, error) {
+	if path == "" {
+		return 0, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read legacy file %s: %w", path, err)
+	}
+
+	var indexes []int
+	if err := json.Unmarshal(data, &indexes); err != nil {
+		return 0, fmt.Errorf("failed to decode legacy file %s: %w", path, err)
+	}
+
+	imported := 0
+	now := time.Now()
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(metaBucket).Get(legacyImportedKey) != nil {
+			return nil
+		}
+
+		for _, index := range indexes {
+			legacy := legacyIndex{Index: index, ImportedAt: now}
+			if err := putJSON(tx, legacyIndexesBucket, indexKey(index), legacy); err != nil {
+				return err
+			}
+			imported++
+		}
+
+		return tx.Bucket(metaBucket).Put(legacyImportedKey, []byte(now.Format(time.RFC3339)))
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to import legacy file %s: %w", path, err)
+	}
+
+	if err := os.Rename(path, path+".imported"); err != nil {
+		return imported, fmt.Errorf("failed to rename imported legacy file %s: %w", path, err)
+	}
+
+	return imported, nil
+}
+
+// migrateSchema upgrades the bucket layout of an existing store file to schemaVersion.
+func migrateSchema(tx *bolt.Tx) error {
+	meta := tx.Bucket(metaBucket)
+
+	version := 1
+	if data := meta.Get(schemaVersionKey); data != nil {
+		parsed, err := strconv.Atoi(string(data))
+		if err != nil {
+			return fmt.Errorf("invalid schema version %q: %w", data, err)
+		}
+		version = parsed
+	}
+
+	if version < 2 {
+		if err := migrateIndexKeys(tx); err != nil {
+			return fmt.Errorf("failed to migrate messages to fingerprint keys: %w", err)
+		}
+	}
+
+	return meta.Put(schemaVersionKey, []byte(strconv.Itoa(schemaVersion)))
+}
+
+// migrateIndexKeys re-keys version 1 messages by fingerprint.
+// Records without a date, such as indexes imported from LAST_SMS_FILE, become legacy indexes.
+func migrateIndexKeys(tx *bolt.Tx) error {
+	messages := tx.Bucket(messagesBucket)
+
+	type oldRecord struct {
+		key     []byte
+		message Message
+	}
+	var records []oldRecord
+	err := messages.ForEach(func(key, value []byte) error {
+		if len(key) != 8 {
+			return nil
+		}
+		var message Message
+		if err := json.Unmarshal(value, &message); err != nil {
+			return fmt.Errorf("failed to decode message %x: %w", key, err)
+		}
+		records = append(records, oldRecord{key: append([]byte(nil), key...), message: message})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if err := messages.Delete(record.key); err != nil {
+			return err
+		}
+
+		message := record.message
+		if message.Date.IsZero() {
+			legacy := legacyIndex{Index: message.Index, ImportedAt: message.SeenAt}
+			if err := putJSON(tx, legacyIndexesBucket, indexKey(message.Index), legacy); err != nil {
+				return err
+			}
+			continue
+		}
+
+		message.ModemDate = message.Date.Format("2006-01-02 15:04:05")
+		message.Fingerprint = Fingerprint(message.Phone, message.ModemDate, message.ContentHash, message.Index)
+		if err := putJSON(tx, messagesBucket, []byte(message.Fingerprint), message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
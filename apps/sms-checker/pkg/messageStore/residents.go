@@ -0,0 +1,81 @@
+package messageStore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Resident is a resident record managed at runtime, in addition to the residents of the config.
+// A stored resident with the same name as a configured one overrides it.
+type Resident struct {
+	Name       string    `json:"name"`                  // Name identifies the resident.
+	Phones     []string  `json:"phones"`                // Phones are the E.164 numbers of the resident.
+	Enabled    bool      `json:"enabled"`               // Enabled is false for blocked residents.
+	Notes      string    `json:"notes,omitempty"`       // Notes is free text about the resident.
+	PinHash    string    `json:"pin_hash,omitempty"`    // PinHash is the bcrypt hash of the resident's PIN.
+	TotpSecret string    `json:"totp_secret,omitempty"` // TotpSecret is the base32 TOTP secret of the resident.
+	UpdatedAt  time.Time `json:"updated_at"`            // UpdatedAt is when the record was last written.
+}
+
+// SaveResident creates or replaces the resident with the same name.
+func (s *Store) SaveResident(resident Resident) error {
+	if resident.Name == "" {
+		return fmt.Errorf("failed to save resident: empty name")
+	}
+
+	resident.UpdatedAt = time.Now()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx, residentsBucket, []byte(resident.Name), resident)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save resident %s: %w", resident.Name, err)
+	}
+	return nil
+}
+
+// DeleteResident removes the stored resident with the name. Removing an unknown name is not an error.
+func (s *Store) DeleteResident(name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(residentsBucket).Delete([]byte(name))
+	})
+}
+
+// ListResidents returns the stored residents ordered by name.
+func (s *Store) ListResidents() ([]Resident, error) {
+	var residents []Resident
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(residentsBucket).ForEach(func(key, _ []byte) error {
+			var resident Resident
+			if _, err := getJSON(tx, residentsBucket, key, &resident); err != nil {
+				return err
+			}
+			residents = append(residents, resident)
+			return nil
+		})
+	})
+	return residents, err
+}
+
+// UseTOTPCounter records that the resident used the TOTP code of a time step counter.
+// It returns false if a code of this or a later step was already used, so a code cannot be replayed.
+func (s *Store) UseTOTPCounter(resident string, counter uint64) (bool, error) {
+	accepted := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(totpCountersBucket)
+		if last := bucket.Get([]byte(resident)); last != nil && counter <= binary.BigEndian.Uint64(last) {
+			return nil
+		}
+
+		value := make([]byte, 8)
+		binary.BigEndian.PutUint64(value, counter)
+		accepted = true
+		return bucket.Put([]byte(resident), value)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to record TOTP counter of %s: %w", resident, err)
+	}
+	return accepted, nil
+}
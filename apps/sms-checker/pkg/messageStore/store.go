@@ -0,0 +1,76 @@
+package messageStore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bucket names used by the store.
+var (
+	messagesBucket      = []byte("messages")
+	legacyIndexesBucket = []byte("legacy_indexes")
+	residentsBucket     = []byte("residents")
+	totpCountersBucket  = []byte("totp_counters")
+	limitsBucket        = []byte("limits")
+	auditBucket         = []byte("audit")
+	metaBucket          = []byte("meta")
+)
+
+// Store is an embedded, transactional store of every SMS seen by the poller,
+// of the residents managed at runtime, of the attempt limits of sender numbers and of admin commands.
+// It is backed by a single bbolt file, every write is atomic and durable.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the store file at path and makes sure all buckets exist.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open message store %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{messagesBucket, legacyIndexesBucket, residentsBucket, totpCountersBucket, limitsBucket, auditBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return fmt.Errorf("failed to create bucket %s: %w", bucket, err)
+			}
+		}
+		return migrateSchema(tx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the store file.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// getJSON reads the JSON value stored under key in bucket into v and reports whether it was found.
+func getJSON(tx *bolt.Tx, bucket, key []byte, v any) (bool, error) {
+	data := tx.Bucket(bucket).Get(key)
+	if data == nil {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to decode %s/%s: %w", bucket, key, err)
+	}
+	return true, nil
+}
+
+// putJSON stores v as JSON under key in bucket.
+func putJSON(tx *bolt.Tx, bucket, key []byte, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s/%s: %w", bucket, key, err)
+	}
+	return tx.Bucket(bucket).Put(key, data)
+}
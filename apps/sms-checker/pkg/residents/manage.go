@@ -0,0 +1,115 @@
+package residents
+
+import (
+	"fmt"
+	"slices"
+
+	"domofon-api/pkg/messageStore"
+)
+
+// Find returns the resident with the name, or owning the phone number, or nil if there is none.
+func (r *Registry) Find(nameOrPhone string) (*Resident, error) {
+	residents, err := r.All()
+	if err != nil {
+		return nil, err
+	}
+
+	normalized, isPhone := NormalizePhone(nameOrPhone)
+	for _, resident := range residents {
+		if resident.Name == nameOrPhone || (isPhone && resident.HasPhone(normalized)) {
+			return &resident, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Save writes the resident to the store, where it overrides a configured resident with the same name.
+// Secrets are copied along, so a configured resident keeps its PIN and TOTP secret once overridden.
+func (r *Registry) Save(resident Resident) error {
+	return r.store.SaveResident(messageStore.Resident{
+		Name:       resident.Name,
+		Phones:     resident.Phones,
+		Enabled:    resident.Enabled,
+		Notes:      resident.Notes,
+		PinHash:    resident.PinHash,
+		TotpSecret: resident.Totp,
+	})
+}
+
+// AddPhone adds the phone number to the resident with the name, creating an enabled resident if there is none.
+// It fails if the number belongs to another resident.
+func (r *Registry) AddPhone(name, phone string) (*Resident, error) {
+	normalized, ok := NormalizePhone(phone)
+	if !ok {
+		return nil, fmt.Errorf("invalid phone number %q", phone)
+	}
+
+	owner, err := r.Lookup(normalized)
+	if err != nil {
+		return nil, err
+	}
+	if owner != nil && owner.Name != name {
+		return nil, fmt.Errorf("%s already belongs to %s", normalized, owner.Name)
+	}
+
+	resident, err := r.Find(name)
+	if err != nil {
+		return nil, err
+	}
+	if resident == nil || resident.Name != name {
+		resident = &Resident{Name: name, Enabled: true}
+	}
+	if !resident.HasPhone(normalized) {
+		resident.Phones = append(slices.Clone(resident.Phones), normalized)
+	}
+
+	resident.Source = SourceStore
+	return resident, r.Save(*resident)
+}
+
+// RemovePhone removes the phone number from its resident.
+// A stored resident left without numbers is deleted; a configured one is overridden with the remaining numbers.
+func (r *Registry) RemovePhone(phone string) (*Resident, error) {
+	resident, err := r.Lookup(phone)
+	if err != nil {
+		return nil, err
+	}
+	if resident == nil {
+		return nil, fmt.Errorf("unknown phone number %s", phone)
+	}
+
+	normalized, _ := NormalizePhone(phone)
+	resident.Phones = slices.DeleteFunc(slices.Clone(resident.Phones), func(p string) bool {
+		return p == normalized
+	})
+
+	if len(resident.Phones) == 0 && resident.Source == SourceStore && !r.isConfigured(resident.Name) {
+		return resident, r.store.DeleteResident(resident.Name)
+	}
+
+	resident.Source = SourceStore
+	return resident, r.Save(*resident)
+}
+
+// SetEnabled blocks or unblocks the resident with the name or phone number.
+func (r *Registry) SetEnabled(nameOrPhone string, enabled bool) (*Resident, error) {
+	resident, err := r.Find(nameOrPhone)
+	if err != nil {
+		return nil, err
+	}
+	if resident == nil {
+		return nil, fmt.Errorf("unknown resident %s", nameOrPhone)
+	}
+
+	resident.Enabled = enabled
+	resident.Source = SourceStore
+	return resident, r.Save(*resident)
+}
+
+// isConfigured reports whether a resident with the name is defined in the config.
+func (r *Registry) isConfigured(name string) bool {
+	return slices.ContainsFunc(r.configured, func(resident Resident) bool {
+		return resident.Name == name
+	})
+}
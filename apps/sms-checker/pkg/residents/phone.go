@@ -0,0 +1,39 @@
+package residents
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizePhone converts a phone number to E.164 ("+79161234567").
+// Russian national formats are understood: "8 916 123-45-67" and "9161234567" become "+79161234567".
+// It returns false for values that are not phone numbers, such as alphanumeric sender names.
+func NormalizePhone(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	hasPlus := strings.HasPrefix(raw, "+")
+
+	var digits strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case unicode.IsSpace(r) || strings.ContainsRune("+-().", r):
+		default:
+			return raw, false
+		}
+	}
+
+	number := digits.String()
+	switch {
+	case !hasPlus && len(number) == 11 && number[0] == '8':
+		number = "7" + number[1:]
+	case !hasPlus && len(number) == 10 && number[0] == '9':
+		number = "7" + number
+	}
+
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return raw, false
+	}
+
+	return "+" + number, true
+}
@@ -0,0 +1,155 @@
+package residents
+
+import (
+	"domofon-api/pkg/messageStore"
+	"log"
+	"slices"
+	"sort"
+
+	"domofon-api.gg/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Source tells where a resident is defined.
+type Source string
+
+const (
+	SourceConfig Source = "config" // SourceConfig residents come from RESIDENTS in conf.yml.
+	SourceStore  Source = "store"  // SourceStore residents are managed at runtime and kept in the message store.
+)
+
+// Resident is a person allowed to open the door by SMS.
+type Resident struct {
+	Name    string   // Name identifies the resident.
+	Phones  []string // Phones are the numbers of the resident, normalised to E.164.
+	Enabled bool     // Enabled is false for blocked residents.
+	Notes   string   // Notes is free text about the resident.
+	PinHash string   // PinHash is the bcrypt hash of the resident's own PIN, empty if the resident has none.
+	Totp    string   // Totp is the base32 TOTP secret of the resident, empty if the resident is not enrolled.
+	Source  Source   // Source tells where the resident is defined.
+}
+
+// HasTOTP reports whether the resident is enrolled for one-time codes.
+func (r *Resident) HasTOTP() bool {
+	return r.Totp != ""
+}
+
+// HasPIN reports whether the resident has an own PIN.
+func (r *Resident) HasPIN() bool {
+	return r.PinHash != ""
+}
+
+// CheckPIN reports whether pin is the resident's PIN.
+func (r *Resident) CheckPIN(pin string) bool {
+	if r.PinHash == "" || pin == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(r.PinHash), []byte(pin)) == nil
+}
+
+// HashPIN returns the bcrypt hash of a PIN, as expected in PIN_HASH.
+func HashPIN(pin string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// HasPhone reports whether the E.164 number belongs to the resident.
+func (r *Resident) HasPhone(phone string) bool {
+	return slices.Contains(r.Phones, phone)
+}
+
+// Registry is the list of residents: the ones of the config, overridden by name by the ones of the store.
+type Registry struct {
+	configured []Resident
+	store      *messageStore.Store
+}
+
+func New(config *config.Config, store *messageStore.Store) *Registry {
+	registry := &Registry{store: store}
+
+	for _, configured := range config.Residents {
+		resident := Resident{
+			Name:    configured.Name,
+			Enabled: configured.IsEnabled(),
+			Notes:   configured.Notes,
+			PinHash: configured.PinHash,
+			Totp:    configured.Totp,
+			Source:  SourceConfig,
+		}
+		for _, phone := range configured.Phones {
+			normalized, ok := NormalizePhone(phone)
+			if !ok {
+				log.Printf("Resident %s: ignoring invalid phone number %q", configured.Name, phone)
+				continue
+			}
+			resident.Phones = append(resident.Phones, normalized)
+		}
+		registry.configured = append(registry.configured, resident)
+	}
+
+	return registry
+}
+
+// All returns every resident, ordered by name.
+func (r *Registry) All() ([]Resident, error) {
+	byName := make(map[string]Resident, len(r.configured))
+	for _, resident := range r.configured {
+		byName[resident.Name] = resident
+	}
+
+	stored, err := r.store.ListResidents()
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range stored {
+		byName[record.Name] = Resident{
+			Name:    record.Name,
+			Phones:  record.Phones,
+			Enabled: record.Enabled,
+			Notes:   record.Notes,
+			PinHash: record.PinHash,
+			Totp:    record.TotpSecret,
+			Source:  SourceStore,
+		}
+	}
+
+	residents := make([]Resident, 0, len(byName))
+	for _, resident := range byName {
+		residents = append(residents, resident)
+	}
+	sort.Slice(residents, func(i, j int) bool {
+		return residents[i].Name < residents[j].Name
+	})
+
+	return residents, nil
+}
+
+// Empty reports whether no resident is defined at all, in which case every sender is rejected unless ALLOW_ANY_SENDER is set.
+func (r *Registry) Empty() (bool, error) {
+	residents, err := r.All()
+	return len(residents) == 0, err
+}
+
+// Lookup returns the resident owning the phone number, or nil if the number is unknown.
+// Disabled residents are returned as well, callers must check Enabled.
+func (r *Registry) Lookup(phone string) (*Resident, error) {
+	normalized, ok := NormalizePhone(phone)
+	if !ok {
+		return nil, nil
+	}
+
+	residents, err := r.All()
+	if err != nil {
+		return nil, err
+	}
+	for _, resident := range residents {
+		if resident.HasPhone(normalized) {
+			return &resident, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,146 @@
+// Package smsCommand parses the text of an SMS into a command.
+//
+// The grammar of a command is:
+//
+//	command = keyword [door] [code] {argument}
+//
+// Words are separated by spaces. The keyword must be the first word and is matched, like the door,
+// case-insensitively after Unicode normalisation and without surrounding punctuation,
+// so "Domofon", "ДОМОФОН" and "открой!" all work.
+// The door is only recognised if it is one of the known door names or aliases.
+// The code is the next word as is, punctuation included, so codes such as "p@ss!" match.
+// Arguments are split on punctuation as well. The code and arguments keep their original case.
+package smsCommand
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrNotCommand is returned when the SMS does not start with a known keyword.
+var ErrNotCommand = errors.New("not a command")
+
+// Spec describes a command of the table.
+type Spec struct {
+	Name     string   // Name is the canonical name the checker dispatches on.
+	Keywords []string // Keywords are the words, in any supported language, that start the command.
+	Door     bool     // Door tells whether an optional door name follows the keyword.
+	Code     bool     // Code tells whether a code follows the keyword and the door.
+	Admin    bool     // Admin tells whether the command is reserved to ADMIN_PHONES, which must send their PIN or one-time code.
+}
+
+// Commands is the table of known commands.
+var Commands = []Spec{
+	{
+		Name:     "open",
+		Keywords: []string{"domofon", "open", "домофон", "дверь", "открой", "открыть", "откройте"},
+		Door:     true,
+		Code:     true,
+	},
+	{Name: "add", Keywords: []string{"add", "добавить"}, Code: true, Admin: true},
+	{Name: "remove", Keywords: []string{"remove", "удалить"}, Code: true, Admin: true},
+	{Name: "block", Keywords: []string{"block", "блок", "заблокировать"}, Code: true, Admin: true},
+	{Name: "unblock", Keywords: []string{"unblock", "разблокировать"}, Code: true, Admin: true},
+	{Name: "list", Keywords: []string{"list", "список"}, Code: true, Admin: true},
+	{Name: "status", Keywords: []string{"status", "статус"}, Code: true, Admin: true},
+	{Name: "pause", Keywords: []string{"pause", "пауза"}, Code: true, Admin: true},
+	{Name: "resume", Keywords: []string{"resume", "возобновить"}, Code: true, Admin: true},
+}
+
+// Command is a parsed SMS command.
+type Command struct {
+	Name    string   // Name is the canonical name of the command.
+	Keyword string   // Keyword is the normalised keyword the command was called with.
+	Door    string   // Door is the canonical name of the door, empty if none was given.
+	Code    string   // Code is the code given after the keyword and the door, empty if none was given.
+	Args    []string // Args are the remaining words.
+	Admin   bool     // Admin tells whether the command is reserved to ADMIN_PHONES.
+}
+
+// Parser parses SMS texts with a command table and a set of door names.
+type Parser struct {
+	keywords map[string]Spec
+	doors    map[string]string
+}
+
+// NewParser returns a parser of the commands of the table.
+// doors maps every accepted door name and alias to the canonical door name; it may be nil.
+func NewParser(commands []Spec, doors map[string]string) *Parser {
+	parser := &Parser{
+		keywords: make(map[string]Spec),
+		doors:    make(map[string]string, len(doors)),
+	}
+	for _, spec := range commands {
+		for _, keyword := range spec.Keywords {
+			parser.keywords[Normalize(keyword)] = spec
+		}
+	}
+	for alias, door := range doors {
+		parser.doors[Normalize(alias)] = door
+	}
+	return parser
+}
+
+// Parse parses the text of an SMS. It returns ErrNotCommand if the text does not start with a known keyword.
+func (p *Parser) Parse(text string) (*Command, error) {
+	words := strings.Fields(norm.NFKC.String(text))
+	if len(words) == 0 {
+		return nil, ErrNotCommand
+	}
+
+	keyword := Normalize(trimSeparators(words[0]))
+	spec, ok := p.keywords[keyword]
+	if !ok {
+		return nil, ErrNotCommand
+	}
+
+	command := &Command{
+		Name:    spec.Name,
+		Keyword: keyword,
+		Admin:   spec.Admin,
+	}
+	rest := words[1:]
+
+	if spec.Door && len(rest) > 0 {
+		if door, ok := p.doors[Normalize(trimSeparators(rest[0]))]; ok {
+			command.Door = door
+			rest = rest[1:]
+		}
+	}
+	if spec.Code && len(rest) > 0 {
+		command.Code = rest[0]
+		rest = rest[1:]
+	}
+	command.Args = Split(strings.Join(rest, " "))
+
+	return command, nil
+}
+
+// Split splits a text into words on spaces and punctuation, after NFKC normalisation.
+// Characters used in phone numbers and codes ("+", "-", "_", "#", "*") are kept inside words.
+func Split(text string) []string {
+	return strings.FieldsFunc(norm.NFKC.String(text), isSeparator)
+}
+
+// trimSeparators returns the word without the punctuation around it.
+func trimSeparators(word string) string {
+	return strings.TrimFunc(word, isSeparator)
+}
+
+// isSeparator reports whether the rune separates words: a space or a punctuation character
+// other than those used in phone numbers and codes.
+func isSeparator(r rune) bool {
+	if unicode.IsSpace(r) {
+		return true
+	}
+	return unicode.IsPunct(r) && !strings.ContainsRune("+-_#*", r)
+}
+
+// Normalize returns the form words are compared in: NFKC, lower case and "ё" folded to "е".
+func Normalize(word string) string {
+	word = strings.ToLower(norm.NFKC.String(word))
+	return strings.ReplaceAll(word, "ё", "е")
+}
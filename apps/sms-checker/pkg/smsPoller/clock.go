@@ -0,0 +1,81 @@
+package smsPoller
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"domofon-api/pkg/messageStore"
+)
+
+// Status is the state of the poller, for monitoring.
+type Status struct {
+	ModemTimezone  string        // ModemTimezone is the timezone the modem dates are read in.
+	ClockDrift     time.Duration // ClockDrift is how far the modem clock is ahead of the host clock, negative if it is behind.
+	ClockCheckedAt time.Time     // ClockCheckedAt is when ClockDrift was last measured, zero if never.
+	ClockDrifting  bool          // ClockDrifting is set when ClockDrift exceeds MODEM_CLOCK_TOLERANCE.
+}
+
+// clockMonitor estimates the drift of the modem clock from the dates of newly received messages.
+// A message found by a poll arrived since the previous one, so its modem date minus the time it was seen
+// is the drift, give or take the poll interval and the delivery delay.
+type clockMonitor struct {
+	tolerance time.Duration
+
+	mu       sync.Mutex
+	status   Status
+	started  bool
+	previous map[string]bool
+}
+
+func newClockMonitor(location *time.Location, tolerance time.Duration) *clockMonitor {
+	return &clockMonitor{
+		tolerance: tolerance,
+		status:    Status{ModemTimezone: location.String()},
+	}
+}
+
+// observe measures the drift on the new messages of a poll.
+// Messages already present on the first poll, or on the previous one, may have arrived long ago and are ignored.
+func (c *clockMonitor) observe(records []messageStore.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := make(map[string]bool, len(records))
+	var drift time.Duration
+	measured := false
+	for _, record := range records {
+		current[record.Fingerprint] = true
+		if !c.started || c.previous[record.Fingerprint] {
+			continue
+		}
+
+		// The newest estimate is the closest: a delivery delay only makes a message look older
+		offset := record.Date.Sub(record.SeenAt)
+		if !measured || offset > drift {
+			drift = offset
+			measured = true
+		}
+	}
+	c.started = true
+	c.previous = current
+
+	if !measured {
+		return
+	}
+
+	drifting := drift > c.tolerance || drift < -c.tolerance
+	if drifting != c.status.ClockDrifting || drifting {
+		log.Printf("Modem clock drift is %v (tolerance %v, timezone %s)\n", drift.Round(time.Second), c.tolerance, c.status.ModemTimezone)
+	}
+	c.status.ClockDrift = drift
+	c.status.ClockCheckedAt = time.Now()
+	c.status.ClockDrifting = drifting
+}
+
+// get returns the current status.
+func (c *clockMonitor) get() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status
+}
@@ -0,0 +1,88 @@
+package smsPoller
+
+import (
+	"domofon-api/pkg/huaweimodem"
+	"domofon-api/pkg/messageStore"
+	"sort"
+	"strings"
+	"time"
+)
+
+// part is an inbox message about to be dispatched, with its store record.
+type part struct {
+	message huaweimodem.SMSMessage
+	record  messageStore.Message
+}
+
+// isFragment reports whether the message looks like a part of a longer message, not the last one.
+// The modem does not tell the part number, so the length decides: every part but the last fills a part
+// of a concatenated SMS exactly, one character less when an escaped or a surrogate character did not fit.
+// A message the modem reports as single is never a fragment; SMSTypeMultipart alone is not trusted,
+// as some modems report it on every message.
+func (p part) isFragment() bool {
+	if p.message.SmsType == huaweimodem.SMSTypeSingle {
+		return false
+	}
+	encoding := huaweimodem.DetectEncoding(p.message.Content)
+	length := huaweimodem.SMSLength(p.message.Content, encoding)
+	return length >= encoding.PartCapacity()-1 && length <= encoding.PartCapacity()
+}
+
+// logicalMessage is what the sender typed: one inbox message, or the parts of a concatenated one.
+type logicalMessage struct {
+	parts []part
+}
+
+// first returns the first part of the message.
+func (m *logicalMessage) first() part {
+	return m.parts[0]
+}
+
+// last returns the last part received.
+func (m *logicalMessage) last() part {
+	return m.parts[len(m.parts)-1]
+}
+
+// content returns the text of all parts joined in order.
+func (m *logicalMessage) content() string {
+	var content strings.Builder
+	for _, p := range m.parts {
+		content.WriteString(p.message.Content)
+	}
+	return content.String()
+}
+
+// incomplete reports whether more parts may still arrive:
+// the last part looks like a fragment and was received less than window ago.
+func (m *logicalMessage) incomplete(window time.Duration, now time.Time) bool {
+	return m.last().isFragment() && now.Sub(m.last().record.Date) < window
+}
+
+// assembleMultipart groups new messages into logical messages.
+// A message from the same sender received within window after a fragment continues it;
+// the parts are ordered by date, then by modem index.
+func assembleMultipart(parts []part, window time.Duration) []*logicalMessage {
+	sort.SliceStable(parts, func(i, j int) bool {
+		if !parts[i].record.Date.Equal(parts[j].record.Date) {
+			return parts[i].record.Date.Before(parts[j].record.Date)
+		}
+		return parts[i].message.Index < parts[j].message.Index
+	})
+
+	var messages []*logicalMessage
+	open := make(map[string]*logicalMessage)
+	for _, next := range parts {
+		phone := next.message.Phone
+		if message, ok := open[phone]; ok && window > 0 &&
+			message.last().isFragment() && next.record.Date.Sub(message.last().record.Date) <= window {
+			message.parts = append(message.parts, next)
+			continue
+		}
+
+		message := &logicalMessage{parts: []part{next}}
+		messages = append(messages, message)
+		open[phone] = message
+	}
+
+	return messages
+}
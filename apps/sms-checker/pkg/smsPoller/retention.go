@@ -0,0 +1,91 @@
+package smsPoller
+
+import (
+	"domofon-api/pkg/huaweimodem"
+	"domofon-api/pkg/messageStore"
+	"log"
+	"slices"
+	"sort"
+	"time"
+)
+
+// RetentionPolicy decides which handled messages are deleted from the modem,
+// so the inbox never fills up and stops receiving (error 113018).
+// Zero values disable the corresponding rule.
+type RetentionPolicy struct {
+	KeepNewest         int           // KeepNewest is the number of newest handled messages kept on the modem.
+	DeleteHandledAfter time.Duration // DeleteHandledAfter is the age after which handled messages are deleted.
+	DeleteSpam         bool          // DeleteSpam deletes messages that are not commands right after they are handled.
+}
+
+// enabled reports whether any retention rule is set.
+func (r RetentionPolicy) enabled() bool {
+	return r.KeepNewest > 0 || r.DeleteHandledAfter > 0 || r.DeleteSpam
+}
+
+// applyRetention deletes the handled messages of the inbox that the retention policy no longer keeps.
+// Messages that were not handled yet are never deleted.
+func (p *SMSPoller) applyRetention(messages []huaweimodem.SMSMessage, spam []messageStore.Message) {
+	if !p.retention.enabled() {
+		return
+	}
+
+	var handled []messageStore.Message
+	for _, message := range messages {
+		record, err := p.newRecord(message)
+		if err != nil {
+			continue
+		}
+		seen, err := p.store.HasMessage(record)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if seen {
+			handled = append(handled, record)
+		}
+	}
+	sort.Slice(handled, func(i, j int) bool {
+		return handled[i].Date.After(handled[j].Date)
+	})
+
+	var toDelete []messageStore.Message
+	if p.retention.DeleteSpam {
+		toDelete = append(toDelete, spam...)
+	}
+	for position, message := range handled {
+		if slices.ContainsFunc(toDelete, func(deleted messageStore.Message) bool {
+			return deleted.Fingerprint == message.Fingerprint
+		}) {
+			continue
+		}
+		if p.retention.KeepNewest > 0 && position >= p.retention.KeepNewest {
+			toDelete = append(toDelete, message)
+			continue
+		}
+		if p.retention.DeleteHandledAfter > 0 && time.Since(message.Date) > p.retention.DeleteHandledAfter {
+			toDelete = append(toDelete, message)
+		}
+	}
+
+	if len(toDelete) == 0 {
+		return
+	}
+
+	indexes := make([]int, 0, len(toDelete))
+	fingerprints := make([]string, 0, len(toDelete))
+	for _, message := range toDelete {
+		indexes = append(indexes, message.Index)
+		fingerprints = append(fingerprints, message.Fingerprint)
+	}
+
+	if err := p.modem.DeleteSMS(indexes...); err != nil {
+		log.Printf("Failed to delete %d SMS from the modem: %v\n", len(indexes), err)
+		return
+	}
+	log.Printf("Deleted %d SMS from the modem: %v\n", len(indexes), indexes)
+
+	if err := p.store.MarkDeleted(fingerprints...); err != nil {
+		log.Println(err)
+	}
+}
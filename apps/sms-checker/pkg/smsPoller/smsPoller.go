@@ -2,10 +2,10 @@ package smsPoller
 
 import (
 	"domofon-api/pkg/huaweimodem"
-	"encoding/json"
+	"domofon-api/pkg/messageStore"
+	"errors"
 	"fmt"
-	"os"
-	"slices"
+	"log"
 	"time"
 
 	"domofon-api.gg/config"
@@ -13,101 +13,272 @@ import (
 
 type SMSPoller struct {
 	modem        *huaweimodem.Device
+	store        *messageStore.Store
 	ticker       *time.Ticker
-	lastSmsIds   []int
-	lastSmsFile  string
 	aliveSmsTime int
+	retention    RetentionPolicy
+
+	multipartWindow time.Duration
+	location        *time.Location
+	clock           *clockMonitor
+
+	// Failed session recoveries back off exponentially, a rejected login stops polling until a restart
+	recoveryFailures int
+	nextPoll         time.Time
+	loginRejected    bool
 }
 
+const (
+	recoveryBackoff    = 5 * time.Second
+	maxRecoveryBackoff = 10 * time.Minute
+)
+
 type SMS struct {
 	Id      int
 	Date    time.Time
 	Phone   string
 	Content string
+	Parts   int // Parts is the number of inbox messages the SMS was reassembled from.
 }
 
-type NewSMSEvent = func(SMS)
+// Decision is what the event handler made of an SMS.
+type Decision int
 
-func New(modem *huaweimodem.Device, config *config.Config) *SMSPoller {
-	poller := &SMSPoller{
-		modem:        modem,
-		lastSmsFile:  config.LastSmsFile,
-		aliveSmsTime: config.SmsAliveTime,
-	}
+const (
+	// DecisionCommand means the SMS was a command for the service, whatever its outcome.
+	DecisionCommand Decision = iota
+	// DecisionSpam means the SMS was not addressed to the service at all.
+	DecisionSpam
+	// DecisionExpired means the poller skipped the SMS because it is older than SMS_ALIVE_TIME.
+	DecisionExpired
+	// DecisionAlreadyRead means the poller skipped the SMS because it was marked read on the modem.
+	DecisionAlreadyRead
+	// DecisionInvalid means the poller skipped the SMS because its date could not be parsed.
+	DecisionInvalid
+)
 
-	err := poller.readDatabase()
-	if err != nil {
-		panic(err)
+func (d Decision) String() string {
+	switch d {
+	case DecisionCommand:
+		return "command"
+	case DecisionSpam:
+		return "spam"
+	case DecisionExpired:
+		return "expired"
+	case DecisionAlreadyRead:
+		return "already_read"
+	case DecisionInvalid:
+		return "invalid"
+	default:
+		return fmt.Sprintf("decision(%d)", int(d))
 	}
+}
 
-	return poller
+// Outcome is what the event handler made of an SMS, recorded in the message store.
+type Outcome struct {
+	Decision Decision // Decision classifies the SMS.
+	Resident string   // Resident is the name of the resident identified as the sender, if any.
 }
 
-func (p *SMSPoller) readDatabase() error {
-	file, err := os.Open(p.lastSmsFile)
+type NewSMSEvent = func(SMS) Outcome
+
+func New(modem *huaweimodem.Device, store *messageStore.Store, config *config.Config) *SMSPoller {
+	location, err := time.LoadLocation(config.ModemTimezone)
 	if err != nil {
-		if os.IsNotExist(err) {
-			fmt.Println("Database not found")
-			p.lastSmsIds = []int{}
-			return nil
-		}
-		return err
+		log.Fatalf("Invalid MODEM_TIMEZONE %q: %v", config.ModemTimezone, err)
 	}
-	defer file.Close()
-
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&p.lastSmsIds)
-	return err
-}
 
-func (p *SMSPoller) writeDatabase() error {
-	file, err := os.Create(p.lastSmsFile)
-	if err != nil {
-		return err
+	poller := &SMSPoller{
+		modem:        modem,
+		store:        store,
+		aliveSmsTime: config.SmsAliveTime,
+		retention: RetentionPolicy{
+			KeepNewest:         config.SmsKeepCount,
+			DeleteHandledAfter: time.Duration(config.SmsDeleteHandledAfter) * time.Hour,
+			DeleteSpam:         config.SmsDeleteSpam,
+		},
+		multipartWindow: time.Duration(config.SmsMultipartWindow) * time.Second,
+		location:        location,
+		clock:           newClockMonitor(location, time.Duration(config.ModemClockTolerance)*time.Second),
 	}
-	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	return encoder.Encode(p.lastSmsIds)
+	return poller
 }
 
 func (p *SMSPoller) poll(event NewSMSEvent) {
+	if p.loginRejected || time.Now().Before(p.nextPoll) {
+		return
+	}
+
 	smsList, err := p.modem.ReadSMSInbox()
 	if err != nil {
-		fmt.Println(err)
+		var recoveryErr *huaweimodem.SessionRecoveryError
+		var apiErr *huaweimodem.APIError
+		switch {
+		case errors.As(err, &recoveryErr):
+			p.recoveryFailed(recoveryErr)
+		case errors.Is(err, huaweimodem.ErrSystemBusy):
+			log.Println("Modem is busy, retrying on next poll")
+		case errors.As(err, &apiErr):
+			log.Printf("Modem returned an error on SMS list: %v\n", apiErr)
+		default:
+			log.Println(err)
+		}
 		return
 	}
+	p.recoveryFailures = 0
+
+	var fresh []part
 	for _, message := range smsList.Messages {
-		if !slices.Contains(p.lastSmsIds, message.Index) {
-			p.lastSmsIds = append(p.lastSmsIds, message.Index)
-			go func() {
-				err := p.writeDatabase()
-				if err != nil {
-					fmt.Println(err)
-				}
-			}()
-
-			date, err := time.Parse("2006-01-02 15:04:05", message.Date)
-			if err != nil {
-				fmt.Println(err)
+		record, dateErr := p.newRecord(message)
+		seen, err := p.store.HasMessage(record)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if seen {
+			continue
+		}
+
+		// A message already read on the modem was handled before, even if the store lost it
+		if message.Smstat == huaweimodem.SMSRead {
+			log.Printf("SMS %d is already marked read on the modem\n", message.Index)
+			p.skip(record, DecisionAlreadyRead)
+			continue
+		}
+
+		if dateErr != nil {
+			log.Println(dateErr)
+			p.skip(record, DecisionInvalid)
+			continue
+		}
+
+		fresh = append(fresh, part{message: message, record: record})
+	}
+
+	records := make([]messageStore.Message, 0, len(fresh))
+	for _, part := range fresh {
+		records = append(records, part.record)
+	}
+	p.clock.observe(records)
+
+	var spam []messageStore.Message
+	for _, message := range assembleMultipart(fresh, p.multipartWindow) {
+		if message.incomplete(p.multipartWindow, time.Now()) {
+			log.Printf("SMS %d from %s may continue, waiting for more parts\n", message.first().message.Index, message.first().message.Phone)
+			continue
+		}
+
+		date := message.last().record.Date
+		// The content is not logged, it holds codes and PINs
+		log.Printf("New SMS %d from %s in %d part(s) (%s | s since %f)\n", message.first().message.Index, message.first().message.Phone, len(message.parts), date.Format(time.RFC850), time.Since(date).Seconds())
+
+		if time.Since(date).Seconds() > float64(p.aliveSmsTime) {
+			log.Printf("SMS %d is too old\n", message.first().message.Index)
+			for _, part := range message.parts {
+				p.skip(part.record, DecisionExpired)
+				p.markRead(part.message.Index)
+			}
+			continue
+		}
+
+		// Record the message before dispatching it, so a crash in the handler never opens the door twice
+		for _, part := range message.parts {
+			record := part.record
+			record.Status = messageStore.StatusPending
+			if err := p.store.SaveMessage(record); err != nil {
+				log.Println(err)
 				return
 			}
+		}
 
-			fmt.Printf("New SMS %v (%s | s since %f)\n", message, date.Format(time.RFC850), time.Since(date).Seconds())
+		outcome := event(SMS{
+			Id:      message.first().message.Index,
+			Date:    date,
+			Phone:   message.first().message.Phone,
+			Content: message.content(),
+			Parts:   len(message.parts),
+		})
 
-			if time.Since(date).Seconds() > float64(p.aliveSmsTime) {
-				fmt.Printf("SMS %d is too old\n", message.Index)
-				continue
+		for _, part := range message.parts {
+			record := part.record
+			record.Status = messageStore.StatusProcessed
+			record.Decision = outcome.Decision.String()
+			record.Resident = outcome.Resident
+			record.ProcessedAt = time.Now()
+			if outcome.Decision == DecisionSpam {
+				spam = append(spam, record)
 			}
-
-			event(SMS{
-				Id:      message.Index,
-				Date:    date,
-				Phone:   message.Phone,
-				Content: message.Content,
-			})
+			if err := p.store.SaveMessage(record); err != nil {
+				log.Println(err)
+			}
+			p.markRead(part.message.Index)
 		}
 	}
+
+	p.applyRetention(smsList.Messages, spam)
+}
+
+// recoveryFailed delays the next poll after a failed session recovery, doubling the delay on each failure.
+// A login refused for wrong credentials or a lockout stops polling: retrying would keep the modem locked out.
+func (p *SMSPoller) recoveryFailed(err *huaweimodem.SessionRecoveryError) {
+	if errors.Is(err, huaweimodem.ErrWrongUsername) || errors.Is(err, huaweimodem.ErrWrongPassword) || errors.Is(err, huaweimodem.ErrLockedOut) {
+		p.loginRejected = true
+		log.Printf("Modem refused the login (error code %d), polling stopped: check MODEM_USER and MODEM_PASSWORD and restart: %v\n", err.Code, err.Err)
+		return
+	}
+
+	backoff := recoveryBackoff << min(p.recoveryFailures, 10)
+	backoff = min(backoff, maxRecoveryBackoff)
+	p.recoveryFailures++
+	p.nextPoll = time.Now().Add(backoff)
+	log.Printf("Modem session lost (error code %d), retrying in %v: %v\n", err.Code, backoff, err.Err)
+}
+
+// newRecord builds the store record of a modem message, identified by its fingerprint.
+// The modem date is read in MODEM_TIMEZONE.
+// The returned error reports a date that could not be parsed; the record is usable regardless.
+func (p *SMSPoller) newRecord(message huaweimodem.SMSMessage) (messageStore.Message, error) {
+	contentHash := messageStore.HashContent(message.Content)
+	record := messageStore.Message{
+		Fingerprint: messageStore.Fingerprint(message.Phone, message.Date, contentHash, message.Index),
+		Index:       message.Index,
+		Phone:       message.Phone,
+		ModemDate:   message.Date,
+		ContentHash: contentHash,
+		SeenAt:      time.Now(),
+	}
+
+	date, err := huaweimodem.ParseSMSDate(message.Date, p.location)
+	if err != nil {
+		return record, err
+	}
+	record.Date = date
+
+	return record, nil
+}
+
+// skip records a message that is not dispatched to the handler.
+func (p *SMSPoller) skip(record messageStore.Message, decision Decision) {
+	record.Status = messageStore.StatusSkipped
+	record.Decision = decision.String()
+	record.ProcessedAt = time.Now()
+	if err := p.store.SaveMessage(record); err != nil {
+		log.Println(err)
+	}
+}
+
+// markRead marks a handled message as read on the modem, so it is not dispatched again
+// even if the local database is lost.
+func (p *SMSPoller) markRead(index int) {
+	if err := p.modem.MarkSMSRead(index); err != nil {
+		log.Println(err)
+	}
+}
+
+// Status returns the state of the poller, including the measured drift of the modem clock.
+func (p *SMSPoller) Status() Status {
+	return p.clock.get()
 }
 
 func (p *SMSPoller) Start(event NewSMSEvent) {
@@ -0,0 +1,184 @@
+// Package smsReply sends templated replies to the sender of a command through the modem.
+package smsReply
+
+import (
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"domofon-api/pkg/huaweimodem"
+
+	"domofon-api.gg/config"
+)
+
+// Result is the outcome of a command the sender is told about. It is also the name of the reply template.
+type Result string
+
+const (
+	ResultOpened       Result = "opened"         // ResultOpened means the door was opened.
+	ResultWrongCode    Result = "wrong_code"     // ResultWrongCode means the code, PIN or one-time code was wrong.
+	ResultNotAllowed   Result = "not_allowed"    // ResultNotAllowed means the number is not allowed to open the door.
+	ResultUnavailable  Result = "unavailable"    // ResultUnavailable means the door could not be opened because of a failure.
+	ResultLocked       Result = "locked"         // ResultLocked means the number is locked out after too many wrong codes.
+	ResultTooManyOpens Result = "too_many_opens" // ResultTooManyOpens means the number reached the hourly cap of opens.
+	ResultPaused       Result = "paused"         // ResultPaused means remote opening is paused by an admin.
+
+	// Usage of the admin commands, answered to an admin who got the arguments wrong.
+	ResultUsageAdd     Result = "usage_add"
+	ResultUsageRemove  Result = "usage_remove"
+	ResultUsageBlock   Result = "usage_block"
+	ResultUsageUnblock Result = "usage_unblock"
+	ResultUsagePause   Result = "usage_pause"
+)
+
+// DefaultTemplates are the templates used for results missing in SMS_REPLY_TEMPLATES.
+// "{time}" is replaced by the time of the reply, "{name}" by the name of the resident.
+var DefaultTemplates = map[Result]string{
+	ResultOpened:       "Дверь открыта в {time}",
+	ResultWrongCode:    "Неверный код",
+	ResultNotAllowed:   "Номер не разрешен",
+	ResultUnavailable:  "Сервис недоступен, попробуйте позже",
+	ResultLocked:       "Слишком много неверных попыток, попробуйте позже",
+	ResultTooManyOpens: "Превышен лимит открытий в час",
+	ResultPaused:       "Открытие по смс временно отключено",
+	ResultUsageAdd:     "Формат: add КОД +79990000000 Имя",
+	ResultUsageRemove:  "Формат: remove КОД +79990000000",
+	ResultUsageBlock:   "Формат: block КОД +79990000000 или Имя",
+	ResultUsageUnblock: "Формат: unblock КОД +79990000000 или Имя",
+	ResultUsagePause:   "Формат: pause КОД 2h (30m, 2h, 1d)",
+}
+
+// Replier sends replies, at most one per number within the configured interval.
+type Replier struct {
+	modem     *huaweimodem.Device
+	enabled   bool
+	unknown   bool
+	interval  time.Duration
+	templates map[Result]string
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+func New(modem *huaweimodem.Device, config *config.Config) *Replier {
+	templates := make(map[Result]string, len(DefaultTemplates))
+	for result, template := range DefaultTemplates {
+		templates[result] = template
+	}
+	// viper lower-cases map keys, so "OPENED" and "opened" are the same template
+	for name, template := range config.SmsReplyTemplates {
+		templates[Result(strings.ToLower(name))] = template
+	}
+
+	return &Replier{
+		modem:     modem,
+		enabled:   config.SmsReplyEnabled,
+		unknown:   config.SmsReplyUnknown,
+		interval:  time.Duration(config.SmsReplyInterval) * time.Second,
+		templates: templates,
+		sent:      make(map[string]time.Time),
+	}
+}
+
+// Reply tells the sender about the result of the command.
+// known is false for numbers that are not in the residents list, they get no reply unless SMS_REPLY_UNKNOWN is set.
+// An empty template disables the reply for that result.
+func (r *Replier) Reply(phone string, known bool, result Result, name string) {
+	if !r.enabled || (!known && !r.unknown) {
+		return
+	}
+
+	text := r.Text(result, name)
+	if text == "" {
+		return
+	}
+
+	if !r.allow(phone, time.Now()) {
+		log.Printf("Reply to %s skipped: rate limited\n", phone)
+		return
+	}
+
+	results, err := r.send(phone, text)
+	if err != nil {
+		log.Printf("Failed to reply to %s: %v\n", phone, err)
+		return
+	}
+	if len(results) > 1 {
+		log.Printf("Reply to %s sent in %d parts\n", phone, len(results))
+	}
+}
+
+// Text returns the text of the template of the result, empty if the template is disabled.
+func (r *Replier) Text(result Result, name string) string {
+	return strings.NewReplacer(
+		"{time}", time.Now().Format("15:04"),
+		"{name}", name,
+	).Replace(r.templates[result])
+}
+
+// Send sends a free text to the number, regardless of SMS_REPLY_ENABLED and of the rate limit.
+// It is used for the answers to admin commands.
+func (r *Replier) Send(phone, text string) error {
+	_, err := r.send(phone, text)
+	return err
+}
+
+// send sends the text and deletes the parts the modem accepted from its sent box,
+// which the inbox retention does not clean, so replies never fill the modem storage (error 113018).
+func (r *Replier) send(phone, text string) ([]huaweimodem.SMSSendResult, error) {
+	results, err := r.modem.SendSMS(phone, text)
+
+	var sent []string
+	for _, result := range results {
+		if result.Err == nil {
+			sent = append(sent, result.Content)
+		}
+	}
+	r.deleteSent(sent)
+
+	return results, err
+}
+
+// deleteSent deletes the messages of the sent box with one of the contents.
+// Messages are matched by content only, the modem may store the number in another format.
+func (r *Replier) deleteSent(contents []string) {
+	if len(contents) == 0 {
+		return
+	}
+
+	var indexes []int
+	for message, err := range r.modem.AllSMS(huaweimodem.SMSListOptions{BoxType: huaweimodem.BoxOutbox}) {
+		if err != nil {
+			log.Printf("Failed to list sent SMS: %v\n", err)
+			return
+		}
+		if slices.Contains(contents, message.Content) {
+			indexes = append(indexes, message.Index)
+		}
+	}
+
+	if err := r.modem.DeleteSMS(indexes...); err != nil {
+		log.Printf("Failed to delete %d sent SMS from the modem: %v\n", len(indexes), err)
+	}
+}
+
+// allow reports whether a reply may be sent to the number now and records it if so.
+func (r *Replier) allow(phone string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for number, last := range r.sent {
+		if now.Sub(last) >= r.interval {
+			delete(r.sent, number)
+		}
+	}
+
+	if _, ok := r.sent[phone]; ok {
+		return false
+	}
+	r.sent[phone] = now
+
+	return true
+}
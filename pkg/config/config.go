@@ -11,13 +11,160 @@ import (
 
 type Config struct {
 	SecretKey      string `yaml:"SECRET_KEY" mapstructure:"SECRET_KEY"`
+	SigningKey     string `yaml:"SIGNING_KEY" mapstructure:"SIGNING_KEY"`
 	ProtectionCode string `yaml:"PROTECTION_CODE" mapstructure:"PROTECTION_CODE"`
 	KeyId          int    `yaml:"KEY_ID" mapstructure:"KEY_ID"`
 	HttpPort       int    `yaml:"HTTP_PORT" mapstructure:"HTTP_PORT"`
 	RefreshToken   string `yaml:"REFRESH_TOKEN" mapstructure:"REFRESH_TOKEN"`
 	ModemUrl       string `yaml:"MODEM_URL" mapstructure:"MODEM_URL"`
+	ModemUser      string `yaml:"MODEM_USER" mapstructure:"MODEM_USER"`
+	ModemPassword  string `yaml:"MODEM_PASSWORD" mapstructure:"MODEM_PASSWORD"`
+	ModemTimezone  string `yaml:"MODEM_TIMEZONE" mapstructure:"MODEM_TIMEZONE"`
 	LastSmsFile    string `yaml:"LAST_SMS_FILE" mapstructure:"LAST_SMS_FILE"`
+	StoreFile      string `yaml:"STORE_FILE" mapstructure:"STORE_FILE"`
+	AuditFile      string `yaml:"AUDIT_FILE" mapstructure:"AUDIT_FILE"`
 	SmsAliveTime   int    `yaml:"SMS_ALIVE_TIME" mapstructure:"SMS_ALIVE_TIME"`
+
+	SmsKeepCount          int  `yaml:"SMS_KEEP_COUNT" mapstructure:"SMS_KEEP_COUNT"`
+	SmsDeleteHandledAfter int  `yaml:"SMS_DELETE_HANDLED_AFTER" mapstructure:"SMS_DELETE_HANDLED_AFTER"`
+	SmsDeleteSpam         bool `yaml:"SMS_DELETE_SPAM" mapstructure:"SMS_DELETE_SPAM"`
+	SmsMultipartWindow    int  `yaml:"SMS_MULTIPART_WINDOW" mapstructure:"SMS_MULTIPART_WINDOW"`
+	ModemClockTolerance   int  `yaml:"MODEM_CLOCK_TOLERANCE" mapstructure:"MODEM_CLOCK_TOLERANCE"`
+
+	Residents             []Resident `yaml:"RESIDENTS" mapstructure:"RESIDENTS"`
+	AllowAnySender        bool       `yaml:"ALLOW_ANY_SENDER" mapstructure:"ALLOW_ANY_SENDER"`
+	RequireProtectionCode bool       `yaml:"REQUIRE_PROTECTION_CODE" mapstructure:"REQUIRE_PROTECTION_CODE"`
+	TotpDrift             int        `yaml:"TOTP_DRIFT" mapstructure:"TOTP_DRIFT"`
+	AdminPhones           []string   `yaml:"ADMIN_PHONES" mapstructure:"ADMIN_PHONES"`
+
+	ApiKeys []ApiKey `yaml:"API_KEYS" mapstructure:"API_KEYS"`
+
+	Doors []Door `yaml:"DOORS" mapstructure:"DOORS"`
+
+	FailedAttemptsLimit  int `yaml:"FAILED_ATTEMPTS_LIMIT" mapstructure:"FAILED_ATTEMPTS_LIMIT"`
+	FailedAttemptsWindow int `yaml:"FAILED_ATTEMPTS_WINDOW" mapstructure:"FAILED_ATTEMPTS_WINDOW"`
+	LockoutTime          int `yaml:"LOCKOUT_TIME" mapstructure:"LOCKOUT_TIME"`
+	OpensPerHour         int `yaml:"OPENS_PER_HOUR" mapstructure:"OPENS_PER_HOUR"`
+
+	SmsReplyEnabled   bool              `yaml:"SMS_REPLY_ENABLED" mapstructure:"SMS_REPLY_ENABLED"`
+	SmsReplyUnknown   bool              `yaml:"SMS_REPLY_UNKNOWN" mapstructure:"SMS_REPLY_UNKNOWN"`
+	SmsReplyInterval  int               `yaml:"SMS_REPLY_INTERVAL" mapstructure:"SMS_REPLY_INTERVAL"`
+	SmsReplyTemplates map[string]string `yaml:"SMS_REPLY_TEMPLATES" mapstructure:"SMS_REPLY_TEMPLATES"`
+}
+
+// Resident is a person allowed to open the door by SMS
+type Resident struct {
+	Name    string   `yaml:"NAME" mapstructure:"NAME"`
+	Phones  []string `yaml:"PHONES" mapstructure:"PHONES"`
+	Enabled *bool    `yaml:"ENABLED" mapstructure:"ENABLED"`
+	Notes   string   `yaml:"NOTES" mapstructure:"NOTES"`
+	PinHash string   `yaml:"PIN_HASH" mapstructure:"PIN_HASH"`
+	Totp    string   `yaml:"TOTP_SECRET" mapstructure:"TOTP_SECRET"`
+}
+
+// Door is a Rosdomofon key that can be opened, such as a gate, an entrance or a parking barrier
+type Door struct {
+	Name      string   `yaml:"NAME" mapstructure:"NAME"`
+	KeyId     int      `yaml:"KEY_ID" mapstructure:"KEY_ID"`
+	Aliases   []string `yaml:"ALIASES" mapstructure:"ALIASES"`
+	Residents []string `yaml:"RESIDENTS" mapstructure:"RESIDENTS"`
+	Primary   bool     `yaml:"PRIMARY" mapstructure:"PRIMARY"`
+}
+
+// defaultDoorName is the name of the door built from KEY_ID when DOORS is not set
+const defaultDoorName = "main"
+
+// Allows reports whether the resident may open the door, every resident may if RESIDENTS is empty
+func (d Door) Allows(resident string) bool {
+	if len(d.Residents) == 0 {
+		return true
+	}
+	for _, name := range d.Residents {
+		if name == resident {
+			return true
+		}
+	}
+	return false
+}
+
+// AllDoors returns the configured doors, or a single primary door with KEY_ID when DOORS is not set
+func (c *Config) AllDoors() []Door {
+	if len(c.Doors) == 0 {
+		return []Door{{Name: defaultDoorName, KeyId: c.KeyId, Primary: true}}
+	}
+	return c.Doors
+}
+
+// FindDoor returns the door with the name or alias, compared case-insensitively
+func (c *Config) FindDoor(name string) (Door, bool) {
+	for _, door := range c.AllDoors() {
+		if strings.EqualFold(door.Name, name) {
+			return door, true
+		}
+		for _, alias := range door.Aliases {
+			if strings.EqualFold(alias, name) {
+				return door, true
+			}
+		}
+	}
+	return Door{}, false
+}
+
+// PrimaryDoor returns the door opened when none is named: the one marked PRIMARY, or the first one
+func (c *Config) PrimaryDoor() Door {
+	doors := c.AllDoors()
+	for _, door := range doors {
+		if door.Primary {
+			return door
+		}
+	}
+	return doors[0]
+}
+
+// ApiKey is a client allowed to call the private routes of domofon-api
+type ApiKey struct {
+	Name    string   `yaml:"NAME" mapstructure:"NAME"`
+	KeyHash string   `yaml:"KEY_HASH" mapstructure:"KEY_HASH"`
+	Scopes  []string `yaml:"SCOPES" mapstructure:"SCOPES"`
+}
+
+// IsEnabled returns the ENABLED flag, residents are enabled when it is not set
+func (r Resident) IsEnabled() bool {
+	return r.Enabled == nil || *r.Enabled
+}
+
+// Secrets returns the configured values that must never be written to the logs
+func (c *Config) Secrets() []string {
+	secrets := []string{c.SecretKey, c.SigningKey, c.ProtectionCode, c.RefreshToken, c.ModemPassword}
+	for _, resident := range c.Residents {
+		secrets = append(secrets, resident.PinHash, resident.Totp)
+	}
+	return secrets
+}
+
+// MinSecretLength is the shortest secret accepted: shorter ones are easy to guess,
+// and the log redaction could not mask them without masking ordinary numbers and words
+const MinSecretLength = 8
+
+// validateConfig requires SIGNING_KEY and rejects secrets shorter than MinSecretLength
+func validateConfig(cfg *Config) error {
+	if cfg.SigningKey == "" {
+		return fmt.Errorf("SIGNING_KEY is required")
+	}
+
+	secrets := []struct{ name, value string }{
+		{"SECRET_KEY", cfg.SecretKey},
+		{"SIGNING_KEY", cfg.SigningKey},
+		{"PROTECTION_CODE", cfg.ProtectionCode},
+		{"REFRESH_TOKEN", cfg.RefreshToken},
+		{"MODEM_PASSWORD", cfg.ModemPassword},
+	}
+	for _, secret := range secrets {
+		if secret.value != "" && len(secret.value) < MinSecretLength {
+			return fmt.Errorf("%s is shorter than %d characters", secret.name, MinSecretLength)
+		}
+	}
+	return nil
 }
 
 func Load() (*Config, error) {
@@ -49,7 +196,7 @@ func Load() (*Config, error) {
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	// Установка значений по умолчанию
-	//setDefaults()
+	setDefaults()
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
@@ -60,9 +207,24 @@ func Load() (*Config, error) {
 	}
 
 	// Валидация обязательных полей
-	//if err := validateConfig(&cfg); err != nil {
-	//	return nil, err
-	//}
+	if err := validateConfig(&cfg); err != nil {
+		return nil, err
+	}
 
 	return &cfg, nil
 }
+
+func setDefaults() {
+	viper.SetDefault("STORE_FILE", "sms.db")
+	viper.SetDefault("AUDIT_FILE", "audit.db")
+	viper.SetDefault("REQUIRE_PROTECTION_CODE", true)
+	viper.SetDefault("TOTP_DRIFT", 1)
+	viper.SetDefault("SMS_REPLY_INTERVAL", 60)
+	viper.SetDefault("FAILED_ATTEMPTS_LIMIT", 5)
+	viper.SetDefault("FAILED_ATTEMPTS_WINDOW", 15)
+	viper.SetDefault("LOCKOUT_TIME", 60)
+	viper.SetDefault("OPENS_PER_HOUR", 10)
+	viper.SetDefault("SMS_MULTIPART_WINDOW", 30)
+	viper.SetDefault("MODEM_TIMEZONE", "Local")
+	viper.SetDefault("MODEM_CLOCK_TOLERANCE", 120)
+}
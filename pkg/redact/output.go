@@ -0,0 +1,101 @@
+package redact
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+var (
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+
+	// redirections are the pipes installed over os.Stdout and os.Stderr
+	redirections []*redirection
+)
+
+// Stdout returns a writer redacting to the standard output of the process, written synchronously.
+func Stdout() io.Writer {
+	return Default.Writer(stdout)
+}
+
+// Stderr returns a writer redacting to the standard error of the process, written synchronously.
+// Loggers that may exit the process, such as log, zap and fx, must write here, so their last line is not lost.
+func Stderr() io.Writer {
+	return Default.Writer(stderr)
+}
+
+// Install routes the output of the process through the Default redactor.
+// The log package writes to Stderr synchronously. Direct writes to os.Stdout and os.Stderr, such as fmt.Println,
+// go through pipes drained in the background, which Close flushes.
+// It must run before loggers capture os.Stdout or os.Stderr.
+func Install() error {
+	stdout, stderr = os.Stdout, os.Stderr
+	log.SetOutput(Stderr())
+
+	out, err := redirect(&os.Stdout)
+	if err != nil {
+		return err
+	}
+	errOut, err := redirect(&os.Stderr)
+	if err != nil {
+		return err
+	}
+	redirections = []*redirection{out, errOut}
+	return nil
+}
+
+// Close flushes what was written to os.Stdout and os.Stderr and restores them. It must run before the process exits.
+func Close() {
+	for _, r := range redirections {
+		r.close()
+	}
+	redirections = nil
+}
+
+// redirection is a pipe whose lines are redacted and copied to the original file.
+type redirection struct {
+	file     **os.File
+	original *os.File
+	pipe     *os.File
+	done     sync.WaitGroup
+}
+
+// redirect replaces the file with a pipe whose lines are redacted and copied to the original file.
+func redirect(file **os.File) (*redirection, error) {
+	reader, pipe, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &redirection{file: file, original: *file, pipe: pipe}
+	*file = pipe
+
+	r.done.Add(1)
+	go func() {
+		defer r.done.Done()
+		defer reader.Close()
+
+		lines := bufio.NewReader(reader)
+		for {
+			line, err := lines.ReadString('\n')
+			if line != "" {
+				io.WriteString(r.original, Default.Redact(line))
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return r, nil
+}
+
+// close restores the original file and waits until the pipe is drained.
+func (r *redirection) close() {
+	*r.file = r.original
+	r.pipe.Close()
+	r.done.Wait()
+}
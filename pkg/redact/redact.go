@@ -0,0 +1,103 @@
+// Package redact masks secrets, tokens, activation links and PIN hashes before they reach the logs.
+package redact
+
+import (
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"domofon-api.gg/config"
+)
+
+// Mask replaces every redacted value.
+const Mask = "[REDACTED]"
+
+// patterns match credentials whose value is not known in advance. The first group and the second one, if any,
+// are kept, what lies between them is masked.
+var patterns = []*regexp.Regexp{
+	// Activation links and query strings: ...activate?token=..., ?code=...
+	regexp.MustCompile(`(?i)([?&](?:token|code|access_token|refresh_token)=)[^&\s"']+`),
+	// Activation requests, whose token is in the path: .../temporary_keys/TOKEN/activate
+	regexp.MustCompile(`(?i)(temporary_keys/)[^/\s"'?]+(/activate)`),
+	// Authorization headers
+	regexp.MustCompile(`(?i)(Bearer\s+)[A-Za-z0-9._~+/=-]+`),
+	// JSON and XML token fields
+	regexp.MustCompile(`(?i)("(?:access_token|refresh_token|password)"\s*:\s*")[^"]+`),
+	regexp.MustCompile(`(?i)(<(?:TokInfo|SesInfo|Password)>)[^<]+`),
+	// bcrypt hashes, such as PIN_HASH
+	regexp.MustCompile(`()\$2[aby]?\$\d{2}\$[./A-Za-z0-9]{53}`),
+}
+
+// Redactor masks known secrets and credential patterns in text.
+type Redactor struct {
+	mu      sync.RWMutex
+	secrets []string
+}
+
+// New returns a redactor of the secrets.
+func New(secrets ...string) *Redactor {
+	r := &Redactor{}
+	r.Add(secrets...)
+	return r
+}
+
+// Add adds secrets, such as tokens obtained at runtime. Values shorter than config.MinSecretLength are ignored,
+// they would mask ordinary numbers and words; the config refuses to load such secrets.
+func (r *Redactor) Add(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, secret := range secrets {
+		if len(secret) < config.MinSecretLength || slices.Contains(r.secrets, secret) {
+			continue
+		}
+		r.secrets = append(r.secrets, secret)
+	}
+	// Longest first, so a secret containing another one is masked whole
+	sort.Slice(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+}
+
+// Redact returns the text with every secret and credential pattern masked.
+func (r *Redactor) Redact(text string) string {
+	r.mu.RLock()
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, Mask)
+	}
+	r.mu.RUnlock()
+
+	for _, pattern := range patterns {
+		text = pattern.ReplaceAllString(text, "${1}"+Mask+"${2}")
+	}
+	return text
+}
+
+// Writer returns a writer redacting everything written to w.
+// Each Write is redacted on its own, so a secret must not be split across writes; log and fmt write whole lines.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return &writer{redactor: r, w: w}
+}
+
+type writer struct {
+	redactor *Redactor
+	w        io.Writer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.redactor.Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Default is the redactor installed over the process output.
+var Default = New()
+
+// Add adds secrets to the Default redactor.
+func Add(secrets ...string) {
+	Default.Add(secrets...)
+}
@@ -0,0 +1,189 @@
+// Package signing signs HTTP requests between sms-checker and domofon-api with HMAC-SHA256.
+//
+// The signature covers the method, the path with its query, a timestamp, a random nonce and the SHA-256 of the body:
+//
+//	METHOD \n REQUEST-URI \n TIMESTAMP \n NONCE \n HEX(SHA-256(BODY))
+//
+// The verifier rejects requests whose timestamp is off by more than its maximum skew and nonces it already saw,
+// so a captured request can neither be altered nor replayed. With a persistent NonceStore this holds across restarts.
+package signing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Headers carrying the signature.
+const (
+	HeaderTimestamp = "X-Domofon-Timestamp" // HeaderTimestamp is the Unix time of signing, in seconds.
+	HeaderNonce     = "X-Domofon-Nonce"     // HeaderNonce is a random value used once.
+	HeaderSignature = "X-Domofon-Signature" // HeaderSignature is the hex HMAC-SHA256 of the canonical request.
+)
+
+// DefaultMaxSkew is the default maximum difference between the timestamp of a request and the verifier clock.
+const DefaultMaxSkew = 5 * time.Minute
+
+var (
+	ErrMissing      = errors.New("request is not signed")
+	ErrStale        = errors.New("request timestamp is out of range")
+	ErrReplayed     = errors.New("request nonce was already used")
+	ErrBadSignature = errors.New("request signature is invalid")
+)
+
+// canonical returns the signed representation of a request.
+func canonical(method, uri, timestamp, nonce string, body []byte) []byte {
+	bodyHash := sha256.Sum256(body)
+	return []byte(method + "\n" + uri + "\n" + timestamp + "\n" + nonce + "\n" + hex.EncodeToString(bodyHash[:]))
+}
+
+// mac returns the HMAC-SHA256 of the message.
+func mac(key, message []byte) []byte {
+	hasher := hmac.New(sha256.New, key)
+	hasher.Write(message)
+	return hasher.Sum(nil)
+}
+
+// readBody reads the body of the request and puts it back, so it can still be sent or handled.
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// Signer signs outgoing requests.
+type Signer struct {
+	key []byte
+}
+
+// NewSigner returns a signer with the shared key.
+func NewSigner(key string) *Signer {
+	return &Signer{key: []byte(key)}
+}
+
+// Sign sets the signature headers of the request.
+func (s *Signer) Sign(r *http.Request) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	nonce := hex.EncodeToString(random)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	signature := mac(s.key, canonical(r.Method, r.URL.RequestURI(), timestamp, nonce, body))
+	r.Header.Set(HeaderTimestamp, timestamp)
+	r.Header.Set(HeaderNonce, nonce)
+	r.Header.Set(HeaderSignature, hex.EncodeToString(signature))
+	return nil
+}
+
+// NonceStore records the nonces of the verified requests.
+type NonceStore interface {
+	// UseNonce records the nonce of a request signed at signedAt and reports whether it was not used before.
+	// Nonces signed before expired may be forgotten, such requests are rejected as stale anyway.
+	UseNonce(nonce string, signedAt, expired time.Time) (bool, error)
+}
+
+// memoryNonces is a NonceStore kept in memory, forgotten on restart.
+type memoryNonces struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+}
+
+func (m *memoryNonces) UseNonce(nonce string, signedAt, expired time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for seen, at := range m.nonces {
+		if at.Before(expired) {
+			delete(m.nonces, seen)
+		}
+	}
+
+	if _, ok := m.nonces[nonce]; ok {
+		return false, nil
+	}
+	m.nonces[nonce] = signedAt
+	return true, nil
+}
+
+// Verifier checks incoming requests.
+type Verifier struct {
+	key     []byte
+	maxSkew time.Duration
+	nonces  NonceStore
+}
+
+// NewVerifier returns a verifier with the shared key, accepting timestamps within maxSkew of its clock.
+// Seen nonces are recorded in nonces; if it is nil they are kept in memory, and a request captured before a restart
+// could be replayed after it within maxSkew.
+func NewVerifier(key string, maxSkew time.Duration, nonces NonceStore) *Verifier {
+	if nonces == nil {
+		nonces = &memoryNonces{nonces: make(map[string]time.Time)}
+	}
+	return &Verifier{
+		key:     []byte(key),
+		maxSkew: maxSkew,
+		nonces:  nonces,
+	}
+}
+
+// Verify checks the signature, the timestamp and the nonce of the request.
+// The nonce is only consumed by requests with a valid signature.
+func (v *Verifier) Verify(r *http.Request) error {
+	timestamp := r.Header.Get(HeaderTimestamp)
+	nonce := r.Header.Get(HeaderNonce)
+	signature, err := hex.DecodeString(r.Header.Get(HeaderSignature))
+	if timestamp == "" || nonce == "" || len(signature) == 0 || err != nil {
+		return ErrMissing
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrStale
+	}
+	now := time.Now()
+	signedAt := time.Unix(seconds, 0)
+	if signedAt.Before(now.Add(-v.maxSkew)) || signedAt.After(now.Add(v.maxSkew)) {
+		return ErrStale
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	expected := mac(v.key, canonical(r.Method, r.URL.RequestURI(), timestamp, nonce, body))
+	if !hmac.Equal(signature, expected) {
+		return ErrBadSignature
+	}
+
+	fresh, err := v.nonces.UseNonce(nonce, signedAt, now.Add(-v.maxSkew))
+	if err != nil {
+		return fmt.Errorf("failed to record nonce: %w", err)
+	}
+	if !fresh {
+		return ErrReplayed
+	}
+	return nil
+}